@@ -8,6 +8,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -146,6 +147,149 @@ func addToZip(z *zip.Writer, path, relpath string, info os.FileInfo) error {
 	return err
 }
 
+// extractZipArchive extracts the zip archive into dest keeping file modes.
+// Entries which escape from dest (zip slip) are rejected.
+func extractZipArchive(src, dest string) error {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return fmt.Errorf("failed to open zip file %s: %w", src, err)
+	}
+	defer r.Close()
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dest, err)
+	}
+	for _, f := range r.File {
+		if err := extractZipFile(f, dest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zipEntryPath returns the path of the zip entry in dest. it returns an error when the path escapes from dest.
+func zipEntryPath(dest, name string) (string, error) {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") || strings.Contains(name, `\`) {
+		return "", fmt.Errorf("illegal file path in zip: %s", name)
+	}
+	path := filepath.Join(dest, filepath.FromSlash(name))
+	if !isSubPath(dest, path) {
+		return "", fmt.Errorf("illegal file path in zip: %s", name)
+	}
+	return path, nil
+}
+
+// isSubPath reports whether path is dest or in dest.
+func isSubPath(dest, path string) bool {
+	rel, err := filepath.Rel(dest, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// checkNoSymlinkInPath returns an error when any existing component of path under dest is a symlink.
+func checkNoSymlinkInPath(dest, path string) error {
+	rel, err := filepath.Rel(dest, path)
+	if err != nil {
+		return err
+	}
+	if rel == "." {
+		return nil
+	}
+	p := dest
+	for _, c := range strings.Split(rel, string(filepath.Separator)) {
+		p = filepath.Join(p, c)
+		st, err := os.Lstat(p)
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if st.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%s is a symlink", p)
+		}
+	}
+	return nil
+}
+
+// checkResolvedPath returns an error when path resolves outside of dest.
+func checkResolvedPath(dest, path string) error {
+	realDest, err := filepath.EvalSymlinks(dest)
+	if err != nil {
+		return err
+	}
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	if !isSubPath(realDest, realPath) {
+		return fmt.Errorf("%s resolves outside of %s", path, dest)
+	}
+	return nil
+}
+
+func extractZipFile(f *zip.File, dest string) error {
+	path, err := zipEntryPath(dest, f.Name)
+	if err != nil {
+		return err
+	}
+	mode := f.Mode()
+	log.Printf("[debug] %s %10d %s %s",
+		mode,
+		f.UncompressedSize64,
+		f.Modified.Format(time.RFC3339),
+		f.Name,
+	)
+	// refuse to write through symlinks extracted before
+	if err := checkNoSymlinkInPath(dest, path); err != nil {
+		return fmt.Errorf("illegal file path in zip: %s: %w", f.Name, err)
+	}
+	if mode.IsDir() {
+		return os.MkdirAll(path, mode.Perm()|0700)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	if err := checkResolvedPath(dest, filepath.Dir(path)); err != nil {
+		return fmt.Errorf("illegal file path in zip: %s: %w", f.Name, err)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s in zip: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	if mode&os.ModeSymlink != 0 {
+		b, err := io.ReadAll(rc)
+		if err != nil {
+			return fmt.Errorf("failed to read %s in zip: %w", f.Name, err)
+		}
+		target := string(b)
+		if filepath.IsAbs(target) || !isSubPath(dest, filepath.Join(filepath.Dir(path), target)) {
+			return fmt.Errorf("illegal symlink in zip: %s -> %s", f.Name, target)
+		}
+		return os.Symlink(target, path)
+	}
+	if !mode.IsRegular() {
+		log.Printf("[warn] skip %s in zip: unsupported file mode %s", f.Name, mode)
+		return nil
+	}
+
+	w, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if _, err := io.Copy(w, rc); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to extract %s: %w", path, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", path, err)
+	}
+	// keep the mode even if umask is applied
+	return os.Chmod(path, mode.Perm())
+}
+
 func (app *App) uploadFunctionToS3(ctx context.Context, f *os.File, bucket, key string) (string, error) {
 	svc := s3.NewFromConfig(app.awsConfig)
 	log.Printf("[debug] PutObjcet to s3://%s/%s", bucket, key)
@@ -4,6 +4,7 @@ import (
 	"archive/zip"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -87,3 +88,105 @@ func TestLoadNotZipArchive(t *testing.T) {
 	}
 	t.Log(err)
 }
+
+func TestExtractZipArchive(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "function.zip")
+	writeTestZip(t, src, []testZipEntry{
+		{Name: "bin/", Mode: os.ModeDir | 0755},
+		{Name: "bin/bootstrap", Mode: 0755, Body: "#!/bin/sh"},
+		{Name: "index.js", Mode: 0644, Body: "exports.handler = async () => {}"},
+		{Name: "current", Mode: os.ModeSymlink | 0777, Body: "bin"},
+	})
+	dest := filepath.Join(dir, "src")
+	if err := lambroll.ExtractZipArchive(src, dest); err != nil {
+		t.Fatal(err)
+	}
+	for name, mode := range map[string]os.FileMode{"bin/bootstrap": 0755, "index.js": 0644} {
+		info, err := os.Stat(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("%s: unexpected mode %s", name, info.Mode())
+		}
+	}
+	if b, _ := os.ReadFile(filepath.Join(dest, "bin/bootstrap")); string(b) != "#!/bin/sh" {
+		t.Errorf("unexpected content %s", string(b))
+	}
+	if target, err := os.Readlink(filepath.Join(dest, "current")); err != nil || target != "bin" {
+		t.Errorf("unexpected symlink %s %v", target, err)
+	}
+}
+
+func TestExtractZipArchiveZipSlip(t *testing.T) {
+	tests := []testZipEntry{
+		{Name: "../evil.txt", Mode: 0644, Body: "evil"},
+		{Name: "dir/../../evil.txt", Mode: 0644, Body: "evil"},
+		{Name: "/tmp/evil.txt", Mode: 0644, Body: "evil"},
+		{Name: "link", Mode: os.ModeSymlink | 0777, Body: "../../etc/passwd"},
+		{Name: "abslink", Mode: os.ModeSymlink | 0777, Body: "/etc/passwd"},
+	}
+	for _, e := range tests {
+		t.Run(e.Name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "function.zip")
+			writeTestZip(t, src, []testZipEntry{e})
+			err := lambroll.ExtractZipArchive(src, filepath.Join(dir, "src"))
+			if err == nil {
+				t.Errorf("%s must be rejected", e.Name)
+			}
+			t.Log(err)
+			if _, err := os.Lstat(filepath.Join(dir, "evil.txt")); err == nil {
+				t.Error("evil.txt is extracted out of the dest")
+			}
+		})
+	}
+}
+
+func TestExtractZipArchiveChainedSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "function.zip")
+	writeTestZip(t, src, []testZipEntry{
+		{Name: "d1/", Mode: os.ModeDir | 0755},
+		{Name: "d1/l", Mode: os.ModeSymlink | 0777, Body: ".."},
+		{Name: "d1/l/l2", Mode: os.ModeSymlink | 0777, Body: ".."},
+		{Name: "d1/l/l2/evil.txt", Mode: 0644, Body: "evil"},
+	})
+	err := lambroll.ExtractZipArchive(src, filepath.Join(dir, "src"))
+	if err == nil {
+		t.Error("chained symlinks must be rejected")
+	}
+	t.Log(err)
+	if _, err := os.Lstat(filepath.Join(dir, "evil.txt")); err == nil {
+		t.Error("evil.txt is extracted out of the dest")
+	}
+}
+
+type testZipEntry struct {
+	Name string
+	Mode os.FileMode
+	Body string
+}
+
+func writeTestZip(t *testing.T, path string, entries []testZipEntry) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.Name, Method: zip.Deflate}
+		h.SetMode(e.Mode)
+		fw, err := w.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(e.Body))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -16,6 +16,8 @@ type Option struct {
 	Function string `help:"Function file path" env:"LAMBROLL_FUNCTION"`
 	LogLevel string `help:"log level (trace, debug, info, warn, error)" default:"info" enum:"trace,debug,info,warn,error" env:"LAMBROLL_LOGLEVEL"`
 	Color    bool   `help:"enable colored output" default:"false" env:"LAMBROLL_COLOR"`
+	Strict   bool   `help:"fail on unknown fields and type mismatches in definition files" default:"false" env:"LAMBROLL_STRICT"`
+	Env      string `help:"environment name to merge the overlay definition files (e.g. function.<env>.json)" env:"LAMBROLL_ENV"`
 
 	Region          *string           `help:"AWS region" env:"AWS_REGION"`
 	Profile         *string           `help:"AWS credential profile name" env:"AWS_PROFILE"`
@@ -25,6 +27,7 @@ type Option struct {
 	Envfile         []string          `help:"environment files" env:"LAMBROLL_ENVFILE"`
 	ExtStr          map[string]string `help:"external string values for Jsonnet" env:"LAMBROLL_EXTSTR"`
 	ExtCode         map[string]string `help:"external code values for Jsonnet" env:"LAMBROLL_EXTCODE"`
+	JPath           []string          `name:"jpath" help:"additional Jsonnet import search paths" env:"LAMBROLL_JPATH" sep:"none"`
 }
 
 type CLIOptions struct {
@@ -32,16 +35,24 @@ type CLIOptions struct {
 
 	Deploy   *DeployOption   `cmd:"deploy" help:"deploy or create function"`
 	Init     *InitOption     `cmd:"init" help:"init function.json"`
+	Import   *ImportOption   `cmd:"import" help:"import functions from AWS SAM or CloudFormation template"`
+	Export   *ExportOption   `cmd:"export" help:"export function as Terraform or CloudFormation"`
 	List     *ListOption     `cmd:"list" help:"list functions"`
+	Runtimes *RuntimesOption `cmd:"runtimes" help:"report functions on deprecated runtimes"`
 	Rollback *RollbackOption `cmd:"rollback" help:"rollback function"`
 	Invoke   *InvokeOption   `cmd:"invoke" help:"invoke function"`
+	Event    *EventOption    `cmd:"event" help:"generate event payloads for invoke"`
 	Archive  *ArchiveOption  `cmd:"archive" help:"archive function"`
 	Logs     *LogsOption     `cmd:"logs" help:"show logs of function"`
+	Stats    *StatsOption    `cmd:"stats" help:"show invocation stats of function from logs"`
 	Diff     *DiffOption     `cmd:"diff" help:"show diff of function"`
 	Render   *RenderOption   `cmd:"render" help:"render function.json"`
+	Validate *ValidateOption `cmd:"validate" help:"validate function.json offline"`
+	Lint     *LintOption     `cmd:"lint" help:"lint function.json by rules"`
 	Status   *StatusOption   `cmd:"status" help:"show status of function"`
 	Delete   *DeleteOption   `cmd:"delete" help:"delete function"`
 	Versions *VersionsOption `cmd:"versions" help:"show versions of function"`
+	History  *HistoryOption  `cmd:"history" help:"show deploy history of function"`
 
 	Version struct{} `cmd:"version" help:"show version"`
 }
@@ -113,22 +124,38 @@ func dispatchCLI(ctx context.Context, sub string, usage func(), opts *CLIOptions
 	switch sub {
 	case "init":
 		return app.Init(ctx, opts.Init)
+	case "import":
+		return app.Import(ctx, opts.Import)
+	case "export":
+		return app.Export(ctx, opts.Export)
 	case "list":
 		return app.List(ctx, opts.List)
+	case "runtimes":
+		return app.Runtimes(ctx, opts.Runtimes)
 	case "deploy":
 		return app.Deploy(ctx, opts.Deploy)
 	case "invoke":
 		return app.Invoke(ctx, opts.Invoke)
+	case "event":
+		return app.Event(ctx, opts.Event)
 	case "logs":
 		return app.Logs(ctx, opts.Logs)
+	case "stats":
+		return app.Stats(ctx, opts.Stats)
 	case "versions":
 		return app.Versions(ctx, opts.Versions)
+	case "history":
+		return app.History(ctx, opts.History)
 	case "archive":
 		return app.Archive(ctx, opts.Archive)
 	case "rollback":
 		return app.Rollback(ctx, opts.Rollback)
 	case "render":
 		return app.Render(ctx, opts.Render)
+	case "validate":
+		return app.Validate(ctx, opts.Validate)
+	case "lint":
+		return app.Lint(ctx, opts.Lint)
 	case "diff":
 		return app.Diff(ctx, opts.Diff)
 	case "delete":
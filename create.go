@@ -65,7 +65,7 @@ func (app *App) prepareFunctionCodeForDeploy(ctx context.Context, opt *DeployOpt
 			log.Printf("[info] uploading function %d bytes to s3://%s/%s", info.Size(), *bucket, *key)
 			versionID, err := app.uploadFunctionToS3(ctx, zipfile, *bucket, *key)
 			if err != nil {
-				fmt.Errorf("failed to upload function zip to s3://%s/%s: %w", *bucket, *key, err)
+				return fmt.Errorf("failed to upload function zip to s3://%s/%s: %w", *bucket, *key, err)
 			}
 			if versionID != "" {
 				log.Printf("[info] object created as version %s", versionID)
@@ -100,11 +100,19 @@ func (app *App) create(ctx context.Context, opt *DeployOption, fn *Function) err
 
 	version := "(created)"
 	if !opt.DryRun {
-		fn.Publish = opt.Publish
+		// publish later with the deploy history when --record-history
+		fn.Publish = opt.Publish && !opt.RecordHistory
 		res, err := app.createFunction(ctx, fn)
 		if err != nil {
 			return fmt.Errorf("failed to create function: %w", err)
 		}
+		if opt.Publish && opt.RecordHistory {
+			v, err := app.publishVersion(ctx, *fn.FunctionName, res.CodeSha256, app.newDeployHistory(ctx, opt.Src))
+			if err != nil {
+				return err
+			}
+			res.Version = aws.String(v)
+		}
 		if res.Version != nil {
 			version = *res.Version
 			log.Printf("[info] deployed function version %s", version)
@@ -18,16 +18,18 @@ import (
 
 // DeployOption represens an option for Deploy()
 type DeployOption struct {
-	Src           string `help:"function zip archive or src dir" default:"."`
-	Publish       bool   `help:"publish function" default:"true"`
-	AliasName     string `name:"alias" help:"alias name for publish" default:"current"`
-	AliasToLatest bool   `help:"set alias to unpublished $LATEST version" default:"false"`
-	DryRun        bool   `help:"dry run" default:"false"`
-	SkipArchive   bool   `help:"skip to create zip archive. requires Code.S3Bucket and Code.S3Key in function definition" default:"false"`
-	KeepVersions  int    `help:"Number of latest versions to keep. Older versions will be deleted. (Optional value: default 0)." default:"0"`
-	Ignore        string `help:"ignore fields by jq queries in function.json" default:""`
-	FunctionURL   string `help:"path to function-url definiton" default:"" env:"LAMBROLL_FUNCTION_URL"`
-	SkipFunction  bool   `help:"skip to deploy a function. deploy function-url only" default:"false"`
+	Src           string   `help:"function zip archive or src dir" default:"."`
+	Publish       bool     `help:"publish function" default:"true"`
+	AliasName     string   `name:"alias" help:"alias name for publish" default:"current"`
+	AliasToLatest bool     `help:"set alias to unpublished $LATEST version" default:"false"`
+	DryRun        bool     `help:"dry run" default:"false"`
+	SkipArchive   bool     `help:"skip to create zip archive. requires Code.S3Bucket and Code.S3Key in function definition" default:"false"`
+	KeepVersions  int      `help:"Number of latest versions to keep. Older versions will be deleted. (Optional value: default 0)." default:"0"`
+	Ignore        string   `help:"ignore fields by jq queries in function.json" default:""`
+	FunctionURL   string   `help:"path to function-url definiton" default:"" env:"LAMBROLL_FUNCTION_URL"`
+	SkipFunction  bool     `help:"skip to deploy a function. deploy function-url only" default:"false"`
+	RecordHistory bool     `help:"record deploy history (git commit, branch, deployer, timestamp) in the description of the published version" default:"false" env:"LAMBROLL_RECORD_HISTORY"`
+	LintRules     []string `name:"lint-rules" help:"paths or URLs of lint rule files. deploy fails when the function violates the rules" env:"LAMBROLL_LINT_RULES"`
 
 	ExcludeFileOption
 }
@@ -83,6 +85,18 @@ func (app *App) Deploy(ctx context.Context, opt *DeployOption) error {
 		return fmt.Errorf("failed to load function: %w", err)
 	}
 
+	if len(opt.LintRules) > 0 {
+		var fu *FunctionURL
+		if opt.FunctionURL != "" {
+			if fu, err = app.loadFunctionUrl(opt.FunctionURL, *fn.FunctionName); err != nil {
+				return fmt.Errorf("failed to load function url config: %w", err)
+			}
+		}
+		if err := app.lint(ctx, fn, fu, opt.LintRules); err != nil {
+			return fmt.Errorf("failed to lint: %w", err)
+		}
+	}
+
 	deployFunctionURL := func(context.Context) error { return nil }
 	if opt.FunctionURL != "" {
 		deployFunctionURL = func(ctx context.Context) error {
@@ -186,7 +200,8 @@ func (app *App) Deploy(ctx context.Context, opt *DeployOption) error {
 	if opt.DryRun {
 		codeIn.DryRun = true
 	} else {
-		codeIn.Publish = opt.Publish
+		// publish later with the deploy history when --record-history
+		codeIn.Publish = opt.Publish && !opt.RecordHistory
 	}
 
 	var res *lambda.UpdateFunctionCodeOutput
@@ -199,6 +214,13 @@ func (app *App) Deploy(ctx context.Context, opt *DeployOption) error {
 	if err := app.ensureLastUpdateStatusSuccessful(ctx, *fn.FunctionName, "updating function code", proc, opt.label()); err != nil {
 		return err
 	}
+	if opt.Publish && opt.RecordHistory && !opt.DryRun {
+		v, err := app.publishVersion(ctx, *fn.FunctionName, res.CodeSha256, app.newDeployHistory(ctx, opt.Src))
+		if err != nil {
+			return err
+		}
+		res.Version = aws.String(v)
+	}
 	if res.Version != nil {
 		newerVersion = *res.Version
 		log.Printf("[info] deployed version %s %s", *res.Version, opt.label())
@@ -27,6 +27,7 @@ type DiffOption struct {
 	Qualifier   *string `help:"the qualifier to compare"`
 	FunctionURL string  `help:"path to function-url definiton" default:"" env:"LAMBROLL_FUNCTION_URL"`
 	Ignore      string  `help:"ignore diff by jq query" default:""`
+	Redact      bool    `help:"mask the resolved secret values" default:"false"`
 
 	ExcludeFileOption
 }
@@ -92,6 +93,9 @@ func (app *App) Diff(ctx context.Context, opt *DiffOption) error {
 
 	remoteJSON, _ := marshalAny(remoteFunc)
 	newJSON, _ := marshalAny(newFunc)
+	if opt.Redact {
+		remoteJSON, newJSON = app.secrets.redactPair(remoteJSON, newJSON)
+	}
 	remoteArn := fullQualifiedFunctionName(app.functionArn(ctx, name), opt.Qualifier)
 
 	if diff, err := jsondiff.Diff(
@@ -102,6 +106,9 @@ func (app *App) Diff(ctx context.Context, opt *DiffOption) error {
 		return fmt.Errorf("failed to diff: %w", err)
 	} else if diff != "" {
 		fmt.Print(coloredDiff(diff))
+		if layers := app.definitionLayers(app.functionFilePath, DefaultFunctionFilenames); layers != nil {
+			printLayers(changedPaths(newJSON, remoteJSON, ""), layers)
+		}
 	}
 
 	if err := validateUpdateFunction(remote, code, newFunc); err != nil {
@@ -182,6 +189,9 @@ func (app *App) diffFunctionURL(ctx context.Context, name string, opt *DiffOptio
 	}
 	r, _ := toGeneralMap(remote, true)
 	l, _ := toGeneralMap(local, true)
+	if opt.Redact {
+		r, l = app.secrets.redactPair(r, l)
+	}
 
 	if diff, err := jsondiff.Diff(
 		&jsondiff.Input{Name: fqName, X: r},
@@ -200,11 +210,17 @@ func (app *App) diffFunctionURL(ctx context.Context, name string, opt *DiffOptio
 	var addsB []byte
 	for _, in := range adds {
 		b, _ := marshalJSON(in)
+		if opt.Redact {
+			b, _ = app.redactJSON(b)
+		}
 		addsB = append(addsB, b...)
 	}
 	var removesB []byte
 	for _, in := range removes {
 		b, _ := marshalJSON(in)
+		if opt.Redact {
+			b, _ = app.redactJSON(b)
+		}
 		removesB = append(removesB, b...)
 	}
 	if ds := diff.Diff(string(removesB), string(addsB)); ds != "" {
@@ -216,6 +232,15 @@ func (app *App) diffFunctionURL(ctx context.Context, name string, opt *DiffOptio
 	return nil
 }
 
+// printLayers prints the definition files which the changed values came from.
+func printLayers(paths []string, layers map[string]string) {
+	for _, p := range paths {
+		if file, ok := layers[p]; ok {
+			fmt.Println(color.CyanString("# %s from %s", p, file))
+		}
+	}
+}
+
 func coloredDiff(src string) string {
 	var b strings.Builder
 	for _, line := range strings.Split(src, "\n") {
@@ -0,0 +1,430 @@
+package lambroll
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventOption represents options for Event()
+type EventOption struct {
+	Generate *EventGenerateOption `cmd:"generate" help:"generate an event payload for invoke"`
+}
+
+// EventGenerateOption represents options for generating an event payload
+type EventGenerateOption struct {
+	Source string `arg:"" enum:"apigateway-v1,apigateway-v2,function-url,sqs,sns,s3,eventbridge,dynamodb,kinesis" help:"event source (apigateway-v1,apigateway-v2,function-url,sqs,sns,s3,eventbridge,dynamodb,kinesis)"`
+
+	Method      string            `default:"GET" help:"HTTP method (apigateway-v1,apigateway-v2,function-url)"`
+	Path        string            `default:"/" help:"HTTP path with query string (apigateway-v1,apigateway-v2,function-url)"`
+	Header      map[string]string `help:"HTTP headers (apigateway-v1,apigateway-v2,function-url)"`
+	Body        string            `default:"" help:"HTTP body, message body, record data or detail"`
+	Bucket      string            `default:"example-bucket" help:"bucket name (s3)"`
+	Key         string            `default:"test/key" help:"object key (s3)"`
+	EventName   string            `default:"" help:"event name (s3: ObjectCreated:Put, dynamodb: INSERT,MODIFY,REMOVE)"`
+	Queue       string            `default:"example-queue" help:"queue name (sqs)"`
+	Topic       string            `default:"example-topic" help:"topic name (sns)"`
+	Table       string            `default:"example-table" help:"table name (dynamodb)"`
+	Stream      string            `default:"example-stream" help:"stream name (kinesis)"`
+	EventSource string            `default:"com.example" help:"event source (eventbridge)"`
+	DetailType  string            `default:"example" help:"detail type (eventbridge)"`
+	AccountID   string            `default:"123456789012" help:"account ID in ARNs"`
+
+	region string
+}
+
+// Event manages event payloads
+func (app *App) Event(ctx context.Context, opt *EventOption) error {
+	opt.Generate.region = app.awsConfig.Region
+	return generateEvent(opt.Generate, os.Stdout)
+}
+
+func generateEvent(opt *EventGenerateOption, w io.Writer) error {
+	if opt.region == "" {
+		opt.region = "us-east-1"
+	}
+	gen, ok := eventGenerators[opt.Source]
+	if !ok {
+		return fmt.Errorf("unknown event source: %s", opt.Source)
+	}
+	ev, err := gen(opt, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to generate %s event: %w", opt.Source, err)
+	}
+	b, err := json.MarshalIndent(ev, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+type eventGenerator func(opt *EventGenerateOption, now time.Time) (any, error)
+
+var eventGenerators = map[string]eventGenerator{
+	"apigateway-v1": generateAPIGatewayV1Event,
+	"apigateway-v2": generateHTTPAPIEvent,
+	"function-url":  generateHTTPAPIEvent,
+	"sqs":           generateSQSEvent,
+	"sns":           generateSNSEvent,
+	"s3":            generateS3Event,
+	"eventbridge":   generateEventBridgeEvent,
+	"dynamodb":      generateDynamoDBEvent,
+	"kinesis":       generateKinesisEvent,
+}
+
+func (opt *EventGenerateOption) arn(service, resource string) string {
+	return fmt.Sprintf("arn:aws:%s:%s:%s:%s", service, opt.region, opt.AccountID, resource)
+}
+
+func (opt *EventGenerateOption) headers() map[string]string {
+	h := map[string]string{
+		"accept":     "*/*",
+		"host":       "example.com",
+		"user-agent": "lambroll/" + Version,
+	}
+	for k, v := range opt.Header {
+		h[strings.ToLower(k)] = v
+	}
+	if opt.Body != "" {
+		if _, ok := h["content-type"]; !ok {
+			h["content-type"] = "application/json"
+		}
+	}
+	return h
+}
+
+func generateAPIGatewayV1Event(opt *EventGenerateOption, now time.Time) (any, error) {
+	u, err := url.Parse(opt.Path)
+	if err != nil {
+		return nil, err
+	}
+	query := map[string]string{}
+	multiQuery := map[string][]string{}
+	for k, v := range u.Query() {
+		query[k] = v[len(v)-1]
+		multiQuery[k] = v
+	}
+	headers := opt.headers()
+	multiHeaders := map[string][]string{}
+	for k, v := range headers {
+		multiHeaders[k] = []string{v}
+	}
+	var body any
+	if opt.Body != "" {
+		body = opt.Body
+	}
+	return map[string]any{
+		"resource":                        "/{proxy+}",
+		"path":                            u.Path,
+		"httpMethod":                      opt.Method,
+		"headers":                         headers,
+		"multiValueHeaders":               multiHeaders,
+		"queryStringParameters":           nilIfEmpty(query),
+		"multiValueQueryStringParameters": nilIfEmpty(multiQuery),
+		"pathParameters":                  map[string]string{"proxy": strings.TrimPrefix(u.Path, "/")},
+		"stageVariables":                  nil,
+		"requestContext": map[string]any{
+			"accountId":        opt.AccountID,
+			"apiId":            "1234567890",
+			"httpMethod":       opt.Method,
+			"path":             "/prod" + u.Path,
+			"protocol":         "HTTP/1.1",
+			"requestId":        newRequestID(),
+			"requestTime":      now.Format("02/Jan/2006:15:04:05 -0700"),
+			"requestTimeEpoch": now.UnixMilli(),
+			"resourcePath":     "/{proxy+}",
+			"stage":            "prod",
+			"identity": map[string]any{
+				"sourceIp":  "127.0.0.1",
+				"userAgent": headers["user-agent"],
+			},
+		},
+		"body":            body,
+		"isBase64Encoded": false,
+	}, nil
+}
+
+// generateHTTPAPIEvent generates an event of API Gateway HTTP API (payload format version 2.0) or function URL.
+func generateHTTPAPIEvent(opt *EventGenerateOption, now time.Time) (any, error) {
+	u, err := url.Parse(opt.Path)
+	if err != nil {
+		return nil, err
+	}
+	query := map[string]string{}
+	for k, v := range u.Query() {
+		query[k] = strings.Join(v, ",")
+	}
+	headers := opt.headers()
+	var cookies []string
+	if c, ok := headers["cookie"]; ok {
+		cookies = strings.Split(c, "; ")
+		delete(headers, "cookie")
+	}
+	apiID := "1234567890"
+	routeKey := "$default"
+	if opt.Source == "function-url" {
+		apiID = "abcdefghijklmnopqrstuvwxyz0123456"
+		headers["host"] = apiID + ".lambda-url." + opt.region + ".on.aws"
+	}
+	ev := map[string]any{
+		"version":               "2.0",
+		"routeKey":              routeKey,
+		"rawPath":               u.Path,
+		"rawQueryString":        u.RawQuery,
+		"cookies":               cookies,
+		"headers":               headers,
+		"queryStringParameters": nilIfEmpty(query),
+		"requestContext": map[string]any{
+			"accountId":    opt.AccountID,
+			"apiId":        apiID,
+			"domainName":   headers["host"],
+			"domainPrefix": strings.SplitN(headers["host"], ".", 2)[0],
+			"http": map[string]any{
+				"method":    opt.Method,
+				"path":      u.Path,
+				"protocol":  "HTTP/1.1",
+				"sourceIp":  "127.0.0.1",
+				"userAgent": headers["user-agent"],
+			},
+			"requestId": newRequestID(),
+			"routeKey":  routeKey,
+			"stage":     "$default",
+			"time":      now.Format("02/Jan/2006:15:04:05 -0700"),
+			"timeEpoch": now.UnixMilli(),
+		},
+		"isBase64Encoded": false,
+	}
+	if opt.Body != "" {
+		ev["body"] = opt.Body
+	}
+	return ev, nil
+}
+
+func generateSQSEvent(opt *EventGenerateOption, now time.Time) (any, error) {
+	return map[string]any{
+		"Records": []any{
+			map[string]any{
+				"messageId":     newRequestID(),
+				"receiptHandle": "MessageReceiptHandle",
+				"body":          opt.Body,
+				"attributes": map[string]string{
+					"ApproximateReceiveCount":          "1",
+					"SentTimestamp":                    strconv.FormatInt(now.UnixMilli(), 10),
+					"SenderId":                         opt.AccountID,
+					"ApproximateFirstReceiveTimestamp": strconv.FormatInt(now.UnixMilli(), 10),
+				},
+				"messageAttributes": map[string]any{},
+				"md5OfBody":         md5Hex(opt.Body),
+				"eventSource":       "aws:sqs",
+				"eventSourceARN":    opt.arn("sqs", opt.Queue),
+				"awsRegion":         opt.region,
+			},
+		},
+	}, nil
+}
+
+func generateSNSEvent(opt *EventGenerateOption, now time.Time) (any, error) {
+	topicArn := opt.arn("sns", opt.Topic)
+	return map[string]any{
+		"Records": []any{
+			map[string]any{
+				"EventSource":          "aws:sns",
+				"EventVersion":         "1.0",
+				"EventSubscriptionArn": topicArn + ":" + newRequestID(),
+				"Sns": map[string]any{
+					"Type":              "Notification",
+					"MessageId":         newRequestID(),
+					"TopicArn":          topicArn,
+					"Subject":           nil,
+					"Message":           opt.Body,
+					"Timestamp":         now.Format("2006-01-02T15:04:05.000Z"),
+					"SignatureVersion":  "1",
+					"Signature":         "EXAMPLE",
+					"SigningCertUrl":    "EXAMPLE",
+					"UnsubscribeUrl":    "EXAMPLE",
+					"MessageAttributes": map[string]any{},
+				},
+			},
+		},
+	}, nil
+}
+
+func generateS3Event(opt *EventGenerateOption, now time.Time) (any, error) {
+	eventName := opt.EventName
+	if eventName == "" {
+		eventName = "ObjectCreated:Put"
+	}
+	return map[string]any{
+		"Records": []any{
+			map[string]any{
+				"eventVersion": "2.1",
+				"eventSource":  "aws:s3",
+				"awsRegion":    opt.region,
+				"eventTime":    now.Format("2006-01-02T15:04:05.000Z"),
+				"eventName":    eventName,
+				"userIdentity": map[string]string{
+					"principalId": "EXAMPLE",
+				},
+				"requestParameters": map[string]string{
+					"sourceIPAddress": "127.0.0.1",
+				},
+				"responseElements": map[string]string{
+					"x-amz-request-id": "EXAMPLE123456789",
+					"x-amz-id-2":       "EXAMPLE123/5678abcdefghijklambdaisawesome/mnopqrstuvwxyzABCDEFGH",
+				},
+				"s3": map[string]any{
+					"s3SchemaVersion": "1.0",
+					"configurationId": "lambroll",
+					"bucket": map[string]any{
+						"name": opt.Bucket,
+						"ownerIdentity": map[string]string{
+							"principalId": "EXAMPLE",
+						},
+						"arn": "arn:aws:s3:::" + opt.Bucket,
+					},
+					"object": map[string]any{
+						"key":       url.QueryEscape(opt.Key),
+						"size":      len(opt.Body),
+						"eTag":      md5Hex(opt.Body),
+						"sequencer": fmt.Sprintf("%016X", now.UnixNano()),
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func generateEventBridgeEvent(opt *EventGenerateOption, now time.Time) (any, error) {
+	var detail any = map[string]any{}
+	if opt.Body != "" {
+		if err := json.Unmarshal([]byte(opt.Body), &detail); err != nil {
+			return nil, fmt.Errorf("--body must be JSON for eventbridge: %w", err)
+		}
+	}
+	return map[string]any{
+		"version":     "0",
+		"id":          newRequestID(),
+		"detail-type": opt.DetailType,
+		"source":      opt.EventSource,
+		"account":     opt.AccountID,
+		"time":        now.Format(time.RFC3339),
+		"region":      opt.region,
+		"resources":   []string{},
+		"detail":      detail,
+	}, nil
+}
+
+func generateDynamoDBEvent(opt *EventGenerateOption, now time.Time) (any, error) {
+	eventName := opt.EventName
+	if eventName == "" {
+		eventName = "INSERT"
+	}
+	image := map[string]any{}
+	if opt.Body != "" {
+		var v map[string]any
+		if err := json.Unmarshal([]byte(opt.Body), &v); err != nil {
+			return nil, fmt.Errorf("--body must be a JSON object for dynamodb: %w", err)
+		}
+		image = toDynamoDBAttributeValue(v).(map[string]any)["M"].(map[string]any)
+	}
+	record := map[string]any{
+		"ApproximateCreationDateTime": now.Unix(),
+		"SequenceNumber":              strconv.FormatInt(now.UnixNano(), 10),
+		"SizeBytes":                   len(opt.Body),
+		"StreamViewType":              "NEW_AND_OLD_IMAGES",
+	}
+	switch eventName {
+	case "INSERT":
+		record["NewImage"] = image
+	case "MODIFY":
+		record["NewImage"] = image
+		record["OldImage"] = image
+	case "REMOVE":
+		record["OldImage"] = image
+	default:
+		return nil, fmt.Errorf("unknown event name for dynamodb: %s", eventName)
+	}
+	return map[string]any{
+		"Records": []any{
+			map[string]any{
+				"eventID":        newRequestID(),
+				"eventName":      eventName,
+				"eventVersion":   "1.1",
+				"eventSource":    "aws:dynamodb",
+				"awsRegion":      opt.region,
+				"dynamodb":       record,
+				"eventSourceARN": opt.arn("dynamodb", "table/"+opt.Table+"/stream/"+now.Format("2006-01-02T15:04:05.000")),
+			},
+		},
+	}, nil
+}
+
+func generateKinesisEvent(opt *EventGenerateOption, now time.Time) (any, error) {
+	return map[string]any{
+		"Records": []any{
+			map[string]any{
+				"kinesis": map[string]any{
+					"kinesisSchemaVersion":        "1.0",
+					"partitionKey":                "1",
+					"sequenceNumber":              strconv.FormatInt(now.UnixNano(), 10),
+					"data":                        base64.StdEncoding.EncodeToString([]byte(opt.Body)),
+					"approximateArrivalTimestamp": float64(now.UnixMilli()) / 1000,
+				},
+				"eventSource":       "aws:kinesis",
+				"eventVersion":      "1.0",
+				"eventID":           "shardId-000000000000:" + strconv.FormatInt(now.UnixNano(), 10),
+				"eventName":         "aws:kinesis:record",
+				"invokeIdentityArn": opt.arn("iam", "role/lambda-role"),
+				"awsRegion":         opt.region,
+				"eventSourceARN":    opt.arn("kinesis", "stream/"+opt.Stream),
+			},
+		},
+	}, nil
+}
+
+// toDynamoDBAttributeValue converts a JSON value to DynamoDB attribute value format.
+func toDynamoDBAttributeValue(v any) any {
+	switch v := v.(type) {
+	case nil:
+		return map[string]any{"NULL": true}
+	case bool:
+		return map[string]any{"BOOL": v}
+	case float64:
+		return map[string]any{"N": strconv.FormatFloat(v, 'f', -1, 64)}
+	case string:
+		return map[string]any{"S": v}
+	case []any:
+		l := make([]any, 0, len(v))
+		for _, e := range v {
+			l = append(l, toDynamoDBAttributeValue(e))
+		}
+		return map[string]any{"L": l}
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = toDynamoDBAttributeValue(e)
+		}
+		return map[string]any{"M": m}
+	}
+	return map[string]any{"S": fmt.Sprint(v)}
+}
+
+func nilIfEmpty[T any](m map[string]T) map[string]T {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+func md5Hex(s string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(s)))
+}
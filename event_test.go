@@ -0,0 +1,76 @@
+package lambroll
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenerateEvent(t *testing.T) {
+	for source := range eventGenerators {
+		t.Run(source, func(t *testing.T) {
+			var buf bytes.Buffer
+			opt := &EventGenerateOption{
+				Source:    source,
+				Method:    "POST",
+				Path:      "/foo?bar=baz",
+				Body:      `{"id":1}`,
+				Bucket:    "my-bucket",
+				Key:       "path/to/object",
+				AccountID: "123456789012",
+				region:    "ap-northeast-1",
+			}
+			if err := generateEvent(opt, &buf); err != nil {
+				t.Fatal(err)
+			}
+			var ev map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &ev); err != nil {
+				t.Fatalf("failed to unmarshal generated event: %s", err)
+			}
+		})
+	}
+}
+
+func TestGenerateHTTPAPIEvent(t *testing.T) {
+	ev, err := generateHTTPAPIEvent(&EventGenerateOption{
+		Source: "function-url",
+		Method: "POST",
+		Path:   "/foo?bar=baz",
+		Header: map[string]string{"X-Foo": "foo"},
+		Body:   "hello",
+		region: "ap-northeast-1",
+	}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := ev.(map[string]any)
+	if m["rawPath"] != "/foo" || m["rawQueryString"] != "bar=baz" || m["body"] != "hello" {
+		t.Errorf("unexpected event %v", m)
+	}
+	headers := m["headers"].(map[string]string)
+	if headers["x-foo"] != "foo" || headers["host"] != "abcdefghijklmnopqrstuvwxyz0123456.lambda-url.ap-northeast-1.on.aws" {
+		t.Errorf("unexpected headers %v", headers)
+	}
+}
+
+func TestToDynamoDBAttributeValue(t *testing.T) {
+	var v any
+	json.Unmarshal([]byte(`{"id":1,"name":"foo","tags":["a",true,null]}`), &v)
+	expected := map[string]any{
+		"M": map[string]any{
+			"id":   map[string]any{"N": "1"},
+			"name": map[string]any{"S": "foo"},
+			"tags": map[string]any{"L": []any{
+				map[string]any{"S": "a"},
+				map[string]any{"BOOL": true},
+				map[string]any{"NULL": true},
+			}},
+		},
+	}
+	if d := cmp.Diff(expected, toDynamoDBAttributeValue(v)); d != "" {
+		t.Errorf("attribute value mismatch: diff:%s", d)
+	}
+}
@@ -0,0 +1,651 @@
+package lambroll
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/fujiwara/tfstate-lookup/tfstate"
+)
+
+// ExportOption represents options for Export()
+type ExportOption struct {
+	Format       string `default:"terraform" enum:"terraform,cloudformation" help:"output format (terraform, cloudformation)"`
+	FunctionURL  string `help:"path to function-url definiton" default:"" env:"LAMBROLL_FUNCTION_URL"`
+	ResourceName string `help:"name of the resources in the output (default: derived from the function name)" default:""`
+	Zip          string `help:"path to the zip archive for the function which has no Code in S3 (terraform only)" default:"function.zip"`
+}
+
+// Export exports the function definition as Terraform HCL or CloudFormation template.
+func (app *App) Export(ctx context.Context, opt *ExportOption) error {
+	path, err := findDefinitionFile(app.functionFilePath, DefaultFunctionFilenames)
+	if err != nil {
+		return err
+	}
+	fn, err := app.loadFunction(path)
+	if err != nil {
+		return fmt.Errorf("failed to load function: %w", err)
+	}
+	sources := []string{path}
+	var fu *FunctionURL
+	if opt.FunctionURL != "" {
+		fu, err = app.loadFunctionUrl(opt.FunctionURL, *fn.FunctionName)
+		if err != nil {
+			return fmt.Errorf("failed to load function-url: %w", err)
+		}
+		sources = append(sources, opt.FunctionURL)
+	}
+
+	refs, err := app.exportRefs(ctx, sources)
+	if err != nil {
+		return err
+	}
+	switch opt.Format {
+	case "terraform":
+		return exportTerraform(os.Stdout, fn, fu, refs, opt)
+	case "cloudformation":
+		return exportCloudFormation(os.Stdout, fn, fu, refs, opt)
+	}
+	return fmt.Errorf("unknown format: %s", opt.Format)
+}
+
+// exportRefs returns references for the account ID, the region and values resolved by tfstate in the sources.
+func (app *App) exportRefs(ctx context.Context, sources []string) (exportRefs, error) {
+	refs := exportRefs{}
+	if id := app.AWSAccountID(ctx); id != "" {
+		refs = append(refs, &exportRef{
+			value:      id,
+			terraform:  "data.aws_caller_identity.current.account_id",
+			cfn:        "AWS::AccountId",
+			dataSource: `data "aws_caller_identity" "current"`,
+		})
+	}
+	if region := app.awsConfig.Region; region != "" {
+		refs = append(refs, &exportRef{
+			value:      region,
+			terraform:  "data.aws_region.current.name",
+			cfn:        "AWS::Region",
+			dataSource: `data "aws_region" "current"`,
+		})
+	}
+	tfRefs, err := app.tfstateExportRefs(ctx, sources)
+	if err != nil {
+		return nil, err
+	}
+	refs = append(refs, tfRefs...)
+	refs.sort()
+	return refs, nil
+}
+
+// exportRef represents a reference to a value in the output.
+type exportRef struct {
+	value      string
+	terraform  string // Terraform expression
+	cfn        string // variable name for Fn::Sub
+	dataSource string // Terraform data source required by the expression
+	whole      bool   // refer only when the whole string equals the value
+	used       bool
+}
+
+type exportRefs []*exportRef
+
+// sort sorts refs by the length of the values in descending order to replace longer values first.
+func (refs exportRefs) sort() {
+	sort.SliceStable(refs, func(i, j int) bool {
+		return len(refs[i].value) > len(refs[j].value)
+	})
+}
+
+// split splits s into literal parts and references for the format.
+func (refs exportRefs) split(s string, format string) []any {
+	for _, r := range refs {
+		if r.value == "" || (format == "terraform" && r.terraform == "") || (format == "cloudformation" && r.cfn == "") {
+			continue
+		}
+		if r.whole {
+			if s == r.value {
+				r.used = true
+				return []any{r}
+			}
+			continue
+		}
+		if i := strings.Index(s, r.value); i >= 0 {
+			r.used = true
+			var parts []any
+			parts = append(parts, refs.split(s[:i], format)...)
+			parts = append(parts, r)
+			parts = append(parts, refs.split(s[i+len(r.value):], format)...)
+			return parts
+		}
+	}
+	if s == "" {
+		return nil
+	}
+	return []any{s}
+}
+
+var tfstateCallRegexp = regexp.MustCompile("(\\w*)tfstate\\s+[`\"]([^`\"]+)[`\"]")
+
+// tfstateExportRefs returns references for values resolved by tfstate functions in the sources.
+// Resources in the root module of --tfstate are referred by their addresses.
+// Outputs, resources in modules and values of --prefixed-tfstate are kept as values,
+// because they are not in the same configuration.
+func (app *App) tfstateExportRefs(ctx context.Context, sources []string) (exportRefs, error) {
+	loc, ok := app.tfstates[""]
+	if !ok {
+		return nil, nil
+	}
+	var state *tfstate.TFState
+	var refs exportRefs
+	for _, src := range sources {
+		b, err := os.ReadFile(src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", src, err)
+		}
+		for _, m := range tfstateCallRegexp.FindAllStringSubmatch(string(b), -1) {
+			prefix, addr := m[1], strings.ReplaceAll(m[2], "'", `"`)
+			if prefix != "" {
+				continue
+			}
+			if strings.HasPrefix(addr, "output.") || strings.HasPrefix(addr, "module.") {
+				log.Printf("[debug] %s cannot be referred from the exported resources", addr)
+				continue
+			}
+			if state == nil {
+				if state, err = tfstate.ReadURL(ctx, loc); err != nil {
+					return nil, fmt.Errorf("failed to read tfstate %s: %w", loc, err)
+				}
+			}
+			obj, err := state.Lookup(addr)
+			if err != nil || obj.Value == nil {
+				continue
+			}
+			if v, ok := obj.Value.(string); ok && v != "" {
+				// a part of the string may be a coincidence (e.g. role/foo in role/foo_1)
+				refs = append(refs, &exportRef{value: v, terraform: addr, whole: true})
+			}
+		}
+	}
+	return refs, nil
+}
+
+var nonIdentifierRegexp = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// exportResourceName returns the resource name for the function name.
+func exportResourceName(opt *ExportOption, functionName, format string) string {
+	if opt.ResourceName != "" {
+		return opt.ResourceName
+	}
+	if format == "cloudformation" {
+		// logical ID must be alphanumeric. my-function -> MyFunction
+		var b strings.Builder
+		for _, w := range nonIdentifierRegexp.Split(functionName, -1) {
+			w = strings.ReplaceAll(w, "_", "")
+			if w != "" {
+				b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+			}
+		}
+		return b.String()
+	}
+	name := nonIdentifierRegexp.ReplaceAllString(functionName, "_")
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// hclBody represents a body of the block in HCL.
+type hclBody struct {
+	items []*hclItem
+}
+
+type hclItem struct {
+	name    string
+	expr    string
+	block   *hclBody
+	comment string
+}
+
+func (b *hclBody) attr(name, expr string) {
+	if expr != "" {
+		b.items = append(b.items, &hclItem{name: name, expr: expr})
+	}
+}
+
+func (b *hclBody) block(header string) *hclBody {
+	body := &hclBody{}
+	b.items = append(b.items, &hclItem{name: header, block: body})
+	return body
+}
+
+func (b *hclBody) comment(s string) {
+	b.items = append(b.items, &hclItem{comment: s})
+}
+
+// write writes the body with the indent. `=` of consecutive attributes are aligned as terraform fmt.
+func (b *hclBody) write(w *strings.Builder, indent string) {
+	for i := 0; i < len(b.items); i++ {
+		item := b.items[i]
+		switch {
+		case item.comment != "":
+			fmt.Fprintf(w, "%s# %s\n", indent, item.comment)
+		case item.block != nil:
+			if i > 0 {
+				w.WriteString("\n")
+			}
+			fmt.Fprintf(w, "%s%s {\n", indent, item.name)
+			item.block.write(w, indent+"  ")
+			fmt.Fprintf(w, "%s}\n", indent)
+			if i+1 < len(b.items) && b.items[i+1].block == nil {
+				w.WriteString("\n")
+			}
+		default:
+			j := i
+			width := 0
+			for ; j < len(b.items) && b.items[j].block == nil && b.items[j].comment == ""; j++ {
+				if len(b.items[j].name) > width {
+					width = len(b.items[j].name)
+				}
+			}
+			for _, a := range b.items[i:j] {
+				expr := strings.ReplaceAll(a.expr, "\n", "\n"+indent)
+				fmt.Fprintf(w, "%s%-*s = %s\n", indent, width, a.name, expr)
+			}
+			i = j - 1
+		}
+	}
+}
+
+var hclIdentifierRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+
+// hclQuote returns the quoted string literal of HCL. template sequences are escaped.
+func hclQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		case (r == '$' || r == '%') && strings.HasPrefix(s[i+1:], "{"):
+			b.WriteRune(r)
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// hclString returns the HCL expression of the string with references.
+func (refs exportRefs) hclString(s string) string {
+	parts := refs.split(s, "terraform")
+	if len(parts) == 1 {
+		if r, ok := parts[0].(*exportRef); ok {
+			return r.terraform
+		}
+	}
+	var b strings.Builder
+	b.WriteString(`"`)
+	for _, p := range parts {
+		switch p := p.(type) {
+		case string:
+			q := hclQuote(p)
+			b.WriteString(q[1 : len(q)-1])
+		case *exportRef:
+			b.WriteString("${" + p.terraform + "}")
+		}
+	}
+	b.WriteString(`"`)
+	return b.String()
+}
+
+func (refs exportRefs) hclStringPtr(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return refs.hclString(*s)
+}
+
+func (refs exportRefs) hclList(ss []string) string {
+	if len(ss) == 0 {
+		return ""
+	}
+	exprs := make([]string, 0, len(ss))
+	for _, s := range ss {
+		exprs = append(exprs, refs.hclString(s))
+	}
+	return "[" + strings.Join(exprs, ", ") + "]"
+}
+
+func (refs exportRefs) hclMap(m map[string]string) string {
+	if len(m) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	body := &hclBody{}
+	for _, k := range keys {
+		name := k
+		if !hclIdentifierRegexp.MatchString(k) {
+			name = hclQuote(k)
+		}
+		body.attr(name, refs.hclString(m[k]))
+	}
+	var b strings.Builder
+	b.WriteString("{\n")
+	body.write(&b, "  ")
+	b.WriteString("}")
+	return b.String()
+}
+
+func hclInt32(v *int32) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(int(*v))
+}
+
+func hclBool(v *bool) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatBool(*v)
+}
+
+func stringsOf[T ~string](vs []T) []string {
+	ss := make([]string, 0, len(vs))
+	for _, v := range vs {
+		ss = append(ss, string(v))
+	}
+	return ss
+}
+
+func exportTerraform(w io.Writer, fn *Function, fu *FunctionURL, refs exportRefs, opt *ExportOption) error {
+	name := exportResourceName(opt, aws.ToString(fn.FunctionName), "terraform")
+	root := &hclBody{}
+	r := root.block(fmt.Sprintf(`resource "aws_lambda_function" %q`, name))
+	r.attr("function_name", refs.hclStringPtr(fn.FunctionName))
+	r.attr("description", refs.hclStringPtr(fn.Description))
+	r.attr("role", refs.hclStringPtr(fn.Role))
+	if fn.PackageType != "" {
+		r.attr("package_type", refs.hclString(string(fn.PackageType)))
+	}
+	r.attr("handler", refs.hclStringPtr(fn.Handler))
+	if fn.Runtime != "" {
+		r.attr("runtime", refs.hclString(string(fn.Runtime)))
+	}
+	r.attr("architectures", refs.hclList(stringsOf(fn.Architectures)))
+	r.attr("memory_size", hclInt32(fn.MemorySize))
+	r.attr("timeout", hclInt32(fn.Timeout))
+	r.attr("layers", refs.hclList(fn.Layers))
+	r.attr("kms_key_arn", refs.hclStringPtr(fn.KMSKeyArn))
+	r.attr("code_signing_config_arn", refs.hclStringPtr(fn.CodeSigningConfigArn))
+	switch {
+	case fn.Code != nil && fn.Code.ImageUri != nil:
+		r.attr("image_uri", refs.hclStringPtr(fn.Code.ImageUri))
+	case fn.Code != nil && fn.Code.S3Bucket != nil:
+		r.attr("s3_bucket", refs.hclStringPtr(fn.Code.S3Bucket))
+		r.attr("s3_key", refs.hclStringPtr(fn.Code.S3Key))
+		r.attr("s3_object_version", refs.hclStringPtr(fn.Code.S3ObjectVersion))
+	default:
+		r.comment(fmt.Sprintf("create %s by lambroll archive --dest %s", opt.Zip, opt.Zip))
+		r.attr("filename", hclQuote(opt.Zip))
+		r.attr("source_code_hash", fmt.Sprintf("filebase64sha256(%s)", hclQuote(opt.Zip)))
+	}
+	if fn.Environment != nil && len(fn.Environment.Variables) > 0 {
+		r.block("environment").attr("variables", refs.hclMap(fn.Environment.Variables))
+	}
+	if c := fn.VpcConfig; c != nil && (len(c.SubnetIds) > 0 || len(c.SecurityGroupIds) > 0) {
+		b := r.block("vpc_config")
+		b.attr("subnet_ids", refs.hclList(c.SubnetIds))
+		b.attr("security_group_ids", refs.hclList(c.SecurityGroupIds))
+		b.attr("ipv6_allowed_for_dual_stack", hclBool(c.Ipv6AllowedForDualStack))
+	}
+	if c := fn.TracingConfig; c != nil && c.Mode != "" {
+		r.block("tracing_config").attr("mode", refs.hclString(string(c.Mode)))
+	}
+	if c := fn.DeadLetterConfig; c != nil && c.TargetArn != nil {
+		r.block("dead_letter_config").attr("target_arn", refs.hclStringPtr(c.TargetArn))
+	}
+	if c := fn.EphemeralStorage; c != nil && c.Size != nil {
+		r.block("ephemeral_storage").attr("size", hclInt32(c.Size))
+	}
+	for _, c := range fn.FileSystemConfigs {
+		b := r.block("file_system_config")
+		b.attr("arn", refs.hclStringPtr(c.Arn))
+		b.attr("local_mount_path", refs.hclStringPtr(c.LocalMountPath))
+	}
+	if c := fn.ImageConfig; c != nil {
+		b := r.block("image_config")
+		b.attr("command", refs.hclList(c.Command))
+		b.attr("entry_point", refs.hclList(c.EntryPoint))
+		b.attr("working_directory", refs.hclStringPtr(c.WorkingDirectory))
+	}
+	if c := fn.LoggingConfig; c != nil {
+		b := r.block("logging_config")
+		if c.LogFormat != "" {
+			b.attr("log_format", refs.hclString(string(c.LogFormat)))
+		}
+		b.attr("log_group", refs.hclStringPtr(c.LogGroup))
+		if c.ApplicationLogLevel != "" {
+			b.attr("application_log_level", refs.hclString(string(c.ApplicationLogLevel)))
+		}
+		if c.SystemLogLevel != "" {
+			b.attr("system_log_level", refs.hclString(string(c.SystemLogLevel)))
+		}
+	}
+	if c := fn.SnapStart; c != nil && c.ApplyOn != "" {
+		r.block("snap_start").attr("apply_on", refs.hclString(string(c.ApplyOn)))
+	}
+	if len(fn.Tags) > 0 {
+		r.attr("tags", refs.hclMap(fn.Tags))
+	}
+
+	functionName := fmt.Sprintf("aws_lambda_function.%s.function_name", name)
+	if fu != nil {
+		c := fu.Config
+		u := root.block(fmt.Sprintf(`resource "aws_lambda_function_url" %q`, name))
+		u.attr("function_name", functionName)
+		u.attr("qualifier", refs.hclStringPtr(c.Qualifier))
+		u.attr("authorization_type", refs.hclString(string(c.AuthType)))
+		if c.InvokeMode != "" {
+			u.attr("invoke_mode", refs.hclString(string(c.InvokeMode)))
+		}
+		if cors := c.Cors; cors != nil {
+			b := u.block("cors")
+			b.attr("allow_credentials", hclBool(cors.AllowCredentials))
+			b.attr("allow_headers", refs.hclList(cors.AllowHeaders))
+			b.attr("allow_methods", refs.hclList(cors.AllowMethods))
+			b.attr("allow_origins", refs.hclList(cors.AllowOrigins))
+			b.attr("expose_headers", refs.hclList(cors.ExposeHeaders))
+			b.attr("max_age", hclInt32(cors.MaxAge))
+		}
+		for i, p := range fu.Permissions {
+			pname := name + "_url"
+			if i > 0 {
+				pname = fmt.Sprintf("%s_url_%d", name, i)
+			}
+			b := root.block(fmt.Sprintf(`resource "aws_lambda_permission" %q`, pname))
+			b.attr("statement_id", hclQuote(p.Sid()))
+			b.attr("action", hclQuote("lambda:InvokeFunctionUrl"))
+			b.attr("function_name", functionName)
+			b.attr("qualifier", refs.hclStringPtr(c.Qualifier))
+			b.attr("principal", refs.hclStringPtr(p.Principal))
+			b.attr("principal_org_id", refs.hclStringPtr(p.PrincipalOrgID))
+			b.attr("source_account", refs.hclStringPtr(p.SourceAccount))
+			b.attr("function_url_auth_type", refs.hclString(string(c.AuthType)))
+		}
+	}
+
+	// data sources required by the references
+	var sb strings.Builder
+	seen := make(map[string]bool)
+	for _, r := range refs {
+		if r.used && r.dataSource != "" && !seen[r.dataSource] {
+			seen[r.dataSource] = true
+			fmt.Fprintf(&sb, "%s {}\n\n", r.dataSource)
+		}
+	}
+	root.write(&sb, "")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// cfnValue converts strings in the value to Fn::Sub with references when they contain the referred values.
+func (refs exportRefs) cfnValue(v any) any {
+	switch v := v.(type) {
+	case string:
+		parts := refs.split(v, "cloudformation")
+		var b strings.Builder
+		sub := false
+		for _, p := range parts {
+			switch p := p.(type) {
+			case string:
+				b.WriteString(strings.ReplaceAll(p, "${", "${!"))
+			case *exportRef:
+				b.WriteString("${" + p.cfn + "}")
+				sub = true
+			}
+		}
+		if !sub {
+			return v
+		}
+		return map[string]any{"Fn::Sub": b.String()}
+	case map[string]any:
+		for k, e := range v {
+			v[k] = refs.cfnValue(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = refs.cfnValue(e)
+		}
+	}
+	return v
+}
+
+func exportCloudFormation(w io.Writer, fn *Function, fu *FunctionURL, refs exportRefs, opt *ExportOption) error {
+	name := exportResourceName(opt, aws.ToString(fn.FunctionName), "cloudformation")
+	x, err := toGeneralMap(fn, true)
+	if err != nil {
+		return err
+	}
+	props := x.(map[string]any)
+	delete(props, "Publish")
+	delete(props, "Tags")
+	parameters := map[string]any{}
+	if props["Code"] == nil {
+		log.Printf("[warn] the function has no Code in S3 or ECR. set CodeS3Bucket and CodeS3Key parameters to the uploaded zip archive")
+		parameters["CodeS3Bucket"] = map[string]any{"Type": "String"}
+		parameters["CodeS3Key"] = map[string]any{"Type": "String"}
+		props["Code"] = map[string]any{
+			"S3Bucket": map[string]any{"Ref": "CodeS3Bucket"},
+			"S3Key":    map[string]any{"Ref": "CodeS3Key"},
+		}
+	}
+	if len(fn.Tags) > 0 {
+		keys := make([]string, 0, len(fn.Tags))
+		for k := range fn.Tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		tags := make([]any, 0, len(keys))
+		for _, k := range keys {
+			tags = append(tags, map[string]any{"Key": k, "Value": fn.Tags[k]})
+		}
+		props["Tags"] = tags
+	}
+	resources := map[string]any{
+		name: map[string]any{
+			"Type":       cfnLambdaFunction,
+			"Properties": refs.cfnValue(props),
+		},
+	}
+
+	if fu != nil {
+		c := fu.Config
+		up := map[string]any{
+			"TargetFunctionArn": map[string]any{"Fn::GetAtt": []any{name, "Arn"}},
+			"AuthType":          string(c.AuthType),
+		}
+		if c.Qualifier != nil {
+			up["Qualifier"] = *c.Qualifier
+		}
+		if c.InvokeMode != "" {
+			up["InvokeMode"] = string(c.InvokeMode)
+		}
+		if c.Cors != nil {
+			cors, err := toGeneralMap(c.Cors, true)
+			if err != nil {
+				return err
+			}
+			up["Cors"] = cors
+		}
+		urlName := name + "Url"
+		resources[urlName] = map[string]any{
+			"Type":       "AWS::Lambda::Url",
+			"Properties": refs.cfnValue(up),
+		}
+		for i, p := range fu.Permissions {
+			pname := urlName + "Permission"
+			if i > 0 {
+				pname = fmt.Sprintf("%s%d", pname, i)
+			}
+			pp := map[string]any{
+				"Action":              "lambda:InvokeFunctionUrl",
+				"FunctionName":        map[string]any{"Ref": name},
+				"FunctionUrlAuthType": string(c.AuthType),
+				"Principal":           aws.ToString(p.Principal),
+			}
+			if c.Qualifier != nil {
+				pp["FunctionName"] = map[string]any{"Fn::Sub": fmt.Sprintf("${%s}:%s", name, *c.Qualifier)}
+			}
+			if p.PrincipalOrgID != nil {
+				pp["PrincipalOrgID"] = *p.PrincipalOrgID
+			}
+			if p.SourceAccount != nil {
+				pp["SourceAccount"] = *p.SourceAccount
+			}
+			resources[pname] = map[string]any{
+				"Type":       "AWS::Lambda::Permission",
+				"DependsOn":  urlName,
+				"Properties": refs.cfnValue(pp),
+			}
+		}
+	}
+
+	tmpl := map[string]any{
+		"AWSTemplateFormatVersion": "2010-09-09",
+		"Resources":                resources,
+	}
+	if len(parameters) > 0 {
+		tmpl["Parameters"] = parameters
+	}
+	b, err := json.MarshalIndent(tmpl, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,137 @@
+package lambroll
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+func newExportTestApp(t *testing.T) (*App, *Function, exportRefs) {
+	t.Helper()
+	t.Setenv("FUNCTION_NAME", "test-function")
+	path := "test/terraform.tfstate"
+	app, err := New(context.Background(), &Option{
+		TFState: &path,
+		PrefixedTFState: map[string]string{
+			"prefix1_": "test/terraform_1.tfstate",
+			"prefix2_": "test/terraform_2.tfstate",
+		},
+		Envfile: []string{"test/env"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.accountID = "123456789012"
+	app.awsConfig.Region = "ap-northeast-1"
+	fn, err := app.loadFunction("test/function.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs, err := app.exportRefs(context.Background(), []string{"test/function.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return app, fn, refs
+}
+
+func TestExportTerraform(t *testing.T) {
+	_, fn, refs := newExportTestApp(t)
+	fu := &FunctionURL{
+		Config: &FunctionURLConfig{
+			AuthType: types.FunctionUrlAuthTypeNone,
+			Cors: &types.Cors{
+				AllowOrigins: []string{"*"},
+				MaxAge:       aws.Int32(3600),
+			},
+		},
+	}
+	if err := fu.Validate(*fn.FunctionName); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := exportTerraform(&buf, fn, fu, refs, &ExportOption{Zip: "function.zip"}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	t.Log(out)
+	for _, pattern := range []string{
+		`(?m)^data "aws_caller_identity" "current" \{\}$`,
+		`(?m)^data "aws_region" "current" \{\}$`,
+		`(?m)^resource "aws_lambda_function" "test_function" \{$`,
+		`(?m)^  function_name +(= "test-function")$`,
+		// resolved by tfstate
+		`(?m)^  role +(= data\.aws_iam_role\.lambda\.arn)$`,
+		// account ID and region
+		`arn += "arn:aws:elasticfilesystem:\$\{data\.aws_region\.current\.name\}:\$\{data\.aws_caller_identity\.current\.account_id\}:access-point/fsap-04fc0858274e7dd9a"`,
+		// values of --prefixed-tfstate are not referred
+		`PREFIXED_TFSTATE_1 += "arn:aws:iam::\$\{data\.aws_caller_identity\.current\.account_id\}:role/test_lambda_role_1"`,
+		`(?m)^  filename +(= "function.zip")$`,
+		`(?m)^  memory_size +(= 128)$`,
+		`(?m)^resource "aws_lambda_function_url" "test_function" \{$`,
+		`(?m)^  function_name +(= aws_lambda_function\.test_function\.function_name)$`,
+		`(?m)^resource "aws_lambda_permission" "test_function_url" \{$`,
+		`(?m)^  principal +(= "\*")$`,
+	} {
+		if !regexp.MustCompile(pattern).MatchString(out) {
+			t.Errorf("%s is not found", pattern)
+		}
+	}
+	if strings.Contains(out, "123456789012") {
+		t.Error("account ID must be referred")
+	}
+}
+
+func TestExportCloudFormation(t *testing.T) {
+	_, fn, refs := newExportTestApp(t)
+	fn.Code = &types.FunctionCode{S3Bucket: aws.String("my-bucket"), S3Key: aws.String("function.zip")}
+	var buf bytes.Buffer
+	if err := exportCloudFormation(&buf, fn, nil, refs, &ExportOption{}); err != nil {
+		t.Fatal(err)
+	}
+	var tmpl struct {
+		Resources map[string]struct {
+			Type       string
+			Properties map[string]any
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &tmpl); err != nil {
+		t.Fatal(err)
+	}
+	r, ok := tmpl.Resources["TestFunction"]
+	if !ok || r.Type != "AWS::Lambda::Function" {
+		t.Fatalf("unexpected resources %s", buf.String())
+	}
+	role, _ := json.Marshal(r.Properties["Role"])
+	if string(role) != `{"Fn::Sub":"arn:aws:iam::${AWS::AccountId}:role/test_lambda_role"}` {
+		t.Errorf("unexpected role %s", role)
+	}
+	code, _ := json.Marshal(r.Properties["Code"])
+	if string(code) != `{"S3Bucket":"my-bucket","S3Key":"function.zip"}` {
+		t.Errorf("unexpected code %s", code)
+	}
+	if _, ok := r.Properties["Publish"]; ok {
+		t.Error("Publish must not be exported")
+	}
+}
+
+func TestHCLQuote(t *testing.T) {
+	tests := map[string]string{
+		`hello`:                  `"hello"`,
+		`say "hi"`:               `"say \"hi\""`,
+		"a\nb":                   `"a\nb"`,
+		`${var.foo} and %{ if }`: `"$${var.foo} and %%{ if }"`,
+		`$5 100%`:                `"$5 100%"`,
+	}
+	for s, expected := range tests {
+		if q := hclQuote(s); q != expected {
+			t.Errorf("hclQuote(%q) = %s, expected %s", s, q, expected)
+		}
+	}
+}
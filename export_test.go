@@ -3,9 +3,13 @@ package lambroll
 var (
 	CreateZipArchive  = createZipArchive
 	ExpandExcludeFile = expandExcludeFile
+	ExtractZipArchive = extractZipArchive
 	LoadZipArchive    = loadZipArchive
 	MergeTags         = mergeTags
 )
 
 type VersionsOutput = versionsOutput
 type VersionsOutputs = versionsOutputs
+type HistoryOutputs = historyOutputs
+
+var ParseDeployHistory = parseDeployHistory
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"sync"
@@ -161,7 +162,7 @@ func (ps *PolicyStatement) PrincipalOrgID() *string {
 }
 
 func (app *App) loadFunctionUrl(path string, functionName string) (*FunctionURL, error) {
-	f, err := loadDefinitionFile[FunctionURL](app, path, DefaultFunctionURLFilenames)
+	f, err := loadOverlaidDefinitionFile[FunctionURL](app, path, DefaultFunctionURLFilenames)
 	if err != nil {
 		return nil, err
 	}
@@ -339,7 +340,9 @@ func (app *App) calcFunctionURLPermissionsDiff(ctx context.Context, fc *Function
 	return adds, removes, nil
 }
 
-func (app *App) initFunctionURL(ctx context.Context, fn *Function, exists bool, opt *InitOption) error {
+// initFunctionURL initializes the function url definition file in dir and returns the path.
+// It returns an empty path when the function url config is not found.
+func (app *App) initFunctionURL(ctx context.Context, fn *Function, exists bool, dir string, opt *InitOption, tz *templatizer) (string, error) {
 	fc, err := app.lambda.GetFunctionUrlConfig(ctx, &lambda.GetFunctionUrlConfigInput{
 		FunctionName: fn.FunctionName,
 		Qualifier:    opt.Qualifier,
@@ -349,7 +352,7 @@ func (app *App) initFunctionURL(ctx context.Context, fn *Function, exists bool,
 		if errors.As(err, &nfe) {
 			if exists {
 				log.Printf("[warn] function url config for %s not found", *fn.FunctionName)
-				return nil
+				return "", nil
 			} else {
 				log.Printf("[info] initializing function url config for %s", *fn.FunctionName)
 				// default settings will be used
@@ -358,7 +361,7 @@ func (app *App) initFunctionURL(ctx context.Context, fn *Function, exists bool,
 				}
 			}
 		} else {
-			return fmt.Errorf("failed to get function url config: %w", err)
+			return "", fmt.Errorf("failed to get function url config: %w", err)
 		}
 	}
 	fqFunctionName := fullQualifiedFunctionName(*fn.FunctionName, opt.Qualifier)
@@ -381,14 +384,14 @@ func (app *App) initFunctionURL(ctx context.Context, fn *Function, exists bool,
 			if errors.As(err, &nfe) {
 				// do nothing
 			} else {
-				return fmt.Errorf("failed to get policy: %w", err)
+				return "", fmt.Errorf("failed to get policy: %w", err)
 			}
 		}
 		if res != nil {
 			log.Printf("[debug] policy for %s: %s", fqFunctionName, *res.Policy)
 			var policy PolicyOutput
 			if err := json.Unmarshal([]byte(*res.Policy), &policy); err != nil {
-				return fmt.Errorf("failed to unmarshal policy: %w", err)
+				return "", fmt.Errorf("failed to unmarshal policy: %w", err)
 			}
 			for _, s := range policy.Statement {
 				if s.Action != "lambda:InvokeFunctionUrl" || s.Effect != "Allow" {
@@ -416,19 +419,21 @@ func (app *App) initFunctionURL(ctx context.Context, fn *Function, exists bool,
 	} else {
 		name = DefaultFunctionURLFilenames[0]
 	}
-	log.Printf("[info] creating %s", name)
+	path := filepath.Join(dir, name)
+	log.Printf("[info] creating %s", path)
 	b, _ := marshalJSON(fu)
+	b = tz.templatize(b, opt.Jsonnet)
 	if opt.Jsonnet {
 		b, err = jsonToJsonnet(b, name)
 		if err != nil {
-			return err
+			return "", err
 		}
 	}
-	if err := app.saveFile(name, b, os.FileMode(0644)); err != nil {
-		return err
+	if err := app.saveFile(path, b, os.FileMode(0644)); err != nil {
+		return "", err
 	}
 
-	return nil
+	return path, nil
 }
 
 func fillDefaultValuesFunctionUrlConfig(fc *FunctionURLConfig) {
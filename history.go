@@ -0,0 +1,225 @@
+package lambroll
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/olekukonko/tablewriter"
+)
+
+// maxVersionDescriptionLength is the max length of the description of a version.
+const maxVersionDescriptionLength = 256
+
+// HistoryOption represents options for History()
+type HistoryOption struct {
+	Output string `default:"table" enum:"table,json,tsv" help:"output format (table,json,tsv)"`
+}
+
+// DeployHistory represents metadata of a deployment recorded in the description of a published version.
+type DeployHistory struct {
+	Lambroll   string    `json:"lambroll"`
+	DeployedBy string    `json:"by,omitempty"`
+	DeployedAt time.Time `json:"at"`
+	GitCommit  string    `json:"commit,omitempty"`
+	GitBranch  string    `json:"branch,omitempty"`
+}
+
+// String returns the compact JSON representation of the history to store in the version description.
+func (h *DeployHistory) String() string {
+	b, _ := json.Marshal(h)
+	if len(b) <= maxVersionDescriptionLength {
+		return string(b)
+	}
+	// too long. drop the optional fields from the longest one
+	hh := *h
+	hh.GitBranch = ""
+	if b, _ = json.Marshal(hh); len(b) <= maxVersionDescriptionLength {
+		return string(b)
+	}
+	hh.DeployedBy = ""
+	b, _ = json.Marshal(hh)
+	return string(b)
+}
+
+// summary returns a short representation of the history for table and tsv output.
+// e.g. "2023-08-30T12:34:56+09:00 abc1234@main"
+func (h *DeployHistory) summary() string {
+	if h == nil {
+		return ""
+	}
+	s := h.DeployedAt.Local().Format(time.RFC3339)
+	if h.GitCommit != "" {
+		s += " " + h.GitCommit
+		if h.GitBranch != "" {
+			s += "@" + h.GitBranch
+		}
+	}
+	return s
+}
+
+// parseDeployHistory parses the description of a version as DeployHistory.
+// It returns nil when the description is not recorded by lambroll.
+func parseDeployHistory(desc string) *DeployHistory {
+	if !strings.HasPrefix(desc, `{"lambroll":`) {
+		return nil
+	}
+	var h DeployHistory
+	if err := json.Unmarshal([]byte(desc), &h); err != nil {
+		log.Printf("[debug] failed to parse deploy history %s: %s", desc, err)
+		return nil
+	}
+	return &h
+}
+
+func (app *App) newDeployHistory(ctx context.Context, src string) *DeployHistory {
+	h := &DeployHistory{
+		Lambroll:   Version,
+		DeployedBy: app.CallerArn(ctx),
+		DeployedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	dir := src
+	if fi, err := os.Stat(src); err == nil && !fi.IsDir() {
+		dir = filepath.Dir(src)
+	}
+	h.GitCommit = gitOutput(dir, "rev-parse", "--short", "HEAD")
+	if branch := gitOutput(dir, "rev-parse", "--abbrev-ref", "HEAD"); branch != "HEAD" {
+		h.GitBranch = branch
+	}
+	return h
+}
+
+func gitOutput(dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	b, err := cmd.Output()
+	if err != nil {
+		log.Printf("[debug] failed to run git %s: %s", strings.Join(args, " "), err)
+		return ""
+	}
+	return string(bytes.TrimSpace(b))
+}
+
+// publishVersion publishes a new version with the deploy history as its description.
+func (app *App) publishVersion(ctx context.Context, name string, codeSha256 *string, h *DeployHistory) (string, error) {
+	log.Printf("[info] publishing version with deploy history %s", h.String())
+	retrier := retryPolicy.Start(ctx)
+	for retrier.Continue() {
+		res, err := app.lambda.PublishVersion(ctx, &lambda.PublishVersionInput{
+			FunctionName: aws.String(name),
+			CodeSha256:   codeSha256,
+			Description:  aws.String(h.String()),
+		})
+		if err != nil {
+			var rce *types.ResourceConflictException
+			if errors.As(err, &rce) {
+				log.Println("[debug] retrying", err)
+				continue
+			}
+			return "", fmt.Errorf("failed to publish version: %w", err)
+		}
+		return aws.ToString(res.Version), nil
+	}
+	return "", fmt.Errorf("failed to publish version (max retries reached)")
+}
+
+type historyOutput struct {
+	Version string `json:"Version"`
+	DeployHistory
+}
+
+type historyOutputs []historyOutput
+
+func (ho historyOutputs) JSON() string {
+	b, _ := json.Marshal(ho)
+	var out bytes.Buffer
+	json.Indent(&out, b, "", "  ")
+	return out.String()
+}
+
+func (ho historyOutputs) TSV() string {
+	buf := new(strings.Builder)
+	for _, h := range ho {
+		buf.WriteString(strings.Join(h.row(), "\t") + "\n")
+	}
+	return buf.String()
+}
+
+func (ho historyOutputs) Table() string {
+	buf := new(strings.Builder)
+	w := tablewriter.NewWriter(buf)
+	w.SetHeader([]string{"Version", "Deployed At", "Deployed By", "Commit", "Branch", "lambroll"})
+	for _, h := range ho {
+		w.Append(h.row())
+	}
+	w.Render()
+	return buf.String()
+}
+
+func (h historyOutput) row() []string {
+	return []string{
+		h.Version,
+		h.DeployedAt.Local().Format(time.RFC3339),
+		h.DeployedBy,
+		h.GitCommit,
+		h.GitBranch,
+		h.Lambroll,
+	}
+}
+
+// History shows the deploy history recorded in the published versions
+func (app *App) History(ctx context.Context, opt *HistoryOption) error {
+	fn, err := app.loadFunction(app.functionFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to load function: %w", err)
+	}
+	name := *fn.FunctionName
+
+	hos := historyOutputs{} // print [] for json output when no history found
+	var nextMarker *string
+	for {
+		res, err := app.lambda.ListVersionsByFunction(ctx, &lambda.ListVersionsByFunctionInput{
+			FunctionName: &name,
+			Marker:       nextMarker,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list versions: %w", err)
+		}
+		for _, v := range res.Versions {
+			h := parseDeployHistory(aws.ToString(v.Description))
+			if h == nil {
+				continue
+			}
+			hos = append(hos, historyOutput{Version: *v.Version, DeployHistory: *h})
+		}
+		if nextMarker = res.NextMarker; nextMarker == nil {
+			break
+		}
+	}
+	if len(hos) == 0 {
+		log.Printf("[info] no deploy history found in versions of %s. use deploy --record-history to record", name)
+	}
+
+	switch opt.Output {
+	case "json":
+		fmt.Println(hos.JSON())
+	case "tsv":
+		fmt.Print(hos.TSV())
+	case "table":
+		fmt.Print(hos.Table())
+	default:
+		return fmt.Errorf("unknown output format: %s", opt.Output)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package lambroll_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fujiwara/lambroll"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDeployHistory(t *testing.T) {
+	h := &lambroll.DeployHistory{
+		Lambroll:   "v1.0.0",
+		DeployedBy: "arn:aws:iam::123456789012:user/alice",
+		DeployedAt: TestFixedTime.UTC(),
+		GitCommit:  "1a2b3c4",
+		GitBranch:  "main",
+	}
+	parsed := lambroll.ParseDeployHistory(h.String())
+	if d := cmp.Diff(h, parsed); d != "" {
+		t.Errorf("history mismatch: diff:%s", d)
+	}
+
+	if lambroll.ParseDeployHistory("hello function") != nil {
+		t.Error("plain description must not be parsed as history")
+	}
+}
+
+func TestDeployHistoryTooLong(t *testing.T) {
+	h := &lambroll.DeployHistory{
+		Lambroll:   "v1.0.0",
+		DeployedBy: "arn:aws:sts::123456789012:assumed-role/" + strings.Repeat("x", 100),
+		DeployedAt: TestFixedTime.UTC(),
+		GitCommit:  "1a2b3c4",
+		GitBranch:  strings.Repeat("b", 200),
+	}
+	s := h.String()
+	if len(s) > 256 {
+		t.Errorf("too long description %d bytes", len(s))
+	}
+	parsed := lambroll.ParseDeployHistory(s)
+	if parsed == nil || parsed.GitCommit != "1a2b3c4" || parsed.GitBranch != "" {
+		t.Errorf("unexpected parsed history %#v", parsed)
+	}
+}
+
+func TestHistoryOutputsEmptyJSON(t *testing.T) {
+	if s := (lambroll.HistoryOutputs{}).JSON(); s != "[]" {
+		t.Errorf("unexpected JSON for empty history %q", s)
+	}
+}
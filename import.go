@@ -0,0 +1,724 @@
+package lambroll
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ImportOption represents options for Import()
+type ImportOption struct {
+	FromSAM  string   `name:"from-sam" required:"" help:"path to AWS SAM or CloudFormation template (YAML or JSON)"`
+	Resource []string `help:"logical IDs of the functions to import (default: all functions)"`
+	Dir      string   `help:"base directory to write the definitions of each function" default:"."`
+	Jsonnet  bool     `help:"render function.json as jsonnet" default:"false"`
+}
+
+const (
+	samServerlessFunction = "AWS::Serverless::Function"
+	cfnLambdaFunction     = "AWS::Lambda::Function"
+)
+
+// samTemplate represents an AWS SAM or CloudFormation template.
+type samTemplate struct {
+	Parameters map[string]map[string]any
+	Globals    map[string]any
+	Resources  map[string]*samResource
+}
+
+// samResource represents a resource in the template.
+type samResource struct {
+	LogicalID  string
+	Type       string
+	Properties map[string]any
+	Condition  string
+}
+
+// samFunction represents a function converted from the template.
+type samFunction struct {
+	LogicalID   string
+	Function    map[string]any
+	FunctionURL map[string]any
+	Events      map[string]*EventGenerateOption
+	Src         string
+	Problems    []string
+}
+
+func (f *samFunction) report(format string, args ...any) {
+	f.Problems = append(f.Problems, fmt.Sprintf(format, args...))
+}
+
+// Import imports functions from AWS SAM or CloudFormation template.
+func (app *App) Import(ctx context.Context, opt *ImportOption) error {
+	t, err := loadSAMTemplate(opt.FromSAM)
+	if err != nil {
+		return err
+	}
+	functions, err := t.functions(opt.Resource)
+	if err != nil {
+		return err
+	}
+	if len(functions) == 0 {
+		log.Printf("[warn] no functions found in %s", opt.FromSAM)
+		return nil
+	}
+	log.Printf("[info] %d functions found in %s", len(functions), opt.FromSAM)
+
+	manifest := &ProjectManifest{}
+	var problems int
+	for _, f := range functions {
+		pf, err := app.importFunction(f, filepath.Dir(opt.FromSAM), opt)
+		if err != nil {
+			return fmt.Errorf("failed to import %s: %w", f.LogicalID, err)
+		}
+		manifest.Functions = append(manifest.Functions, pf)
+		for _, p := range f.Problems {
+			log.Printf("[warn] %s: %s", f.LogicalID, p)
+		}
+		problems += len(f.Problems)
+	}
+
+	path := filepath.Join(opt.Dir, ProjectManifestFilename)
+	log.Printf("[info] creating %s", path)
+	b, _ := marshalJSON(manifest)
+	if err := app.saveFile(path, b, os.FileMode(0644)); err != nil {
+		return err
+	}
+	if problems > 0 {
+		log.Printf("[warn] %d properties are not imported as is. check the messages above and edit the definitions", problems)
+	}
+	return nil
+}
+
+// importFunction writes the definition files of the function into the directory for the function.
+// Paths in the returned ProjectFunction are relative to opt.Dir.
+func (app *App) importFunction(f *samFunction, templateDir string, opt *ImportOption) (*ProjectFunction, error) {
+	dir := filepath.Join(opt.Dir, f.LogicalID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	pf := &ProjectFunction{
+		FunctionName: fmt.Sprint(f.Function["FunctionName"]),
+		Dir:          f.LogicalID,
+	}
+
+	write := func(names []string, v any) (string, error) {
+		name := names[0]
+		if opt.Jsonnet {
+			name = names[1]
+		}
+		path := filepath.Join(dir, name)
+		log.Printf("[info] creating %s", path)
+		b, _ := marshalJSON(v)
+		if opt.Jsonnet {
+			var err error
+			if b, err = jsonToJsonnet(b, name); err != nil {
+				return "", err
+			}
+		}
+		if err := app.saveFile(path, b, os.FileMode(0644)); err != nil {
+			return "", err
+		}
+		return filepath.Join(f.LogicalID, name), nil
+	}
+
+	var err error
+	if pf.Function, err = write(DefaultFunctionFilenames, f.Function); err != nil {
+		return nil, err
+	}
+	if f.FunctionURL != nil {
+		if pf.FunctionURL, err = write(DefaultFunctionURLFilenames, f.FunctionURL); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(f.Events) > 0 {
+		eventsDir := filepath.Join(dir, "events")
+		if err := os.MkdirAll(eventsDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory %s: %w", eventsDir, err)
+		}
+		for name, eopt := range f.Events {
+			path := filepath.Join(eventsDir, name+".json")
+			log.Printf("[info] creating %s (%s event payload for invoke)", path, eopt.Source)
+			var buf bytes.Buffer
+			if err := generateEvent(eopt, &buf); err != nil {
+				return nil, err
+			}
+			if err := app.saveFile(path, buf.Bytes(), os.FileMode(0644)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if f.Src != "" {
+		src := filepath.Join(templateDir, f.Src)
+		pf.Src = src
+		// relative to the manifest
+		absDir, err1 := filepath.Abs(opt.Dir)
+		absSrc, err2 := filepath.Abs(src)
+		if err1 == nil && err2 == nil {
+			if rel, err := filepath.Rel(absDir, absSrc); err == nil {
+				pf.Src = rel
+			}
+		}
+		log.Printf("[info] %s: deploy the code by lambroll deploy --src %s", f.LogicalID, src)
+	}
+	return pf, nil
+}
+
+// loadSAMTemplate loads the template in YAML or JSON.
+// Short form intrinsic functions (e.g. !Ref, !GetAtt) are converted to the full form.
+func loadSAMTemplate(path string) (*samTemplate, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	v, err := yamlNodeValue(&node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	root, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a template", path)
+	}
+
+	t := &samTemplate{
+		Parameters: make(map[string]map[string]any),
+		Resources:  make(map[string]*samResource),
+	}
+	if params, ok := root["Parameters"].(map[string]any); ok {
+		for name, p := range params {
+			if p, ok := p.(map[string]any); ok {
+				t.Parameters[name] = p
+			}
+		}
+	}
+	if globals, ok := root["Globals"].(map[string]any); ok {
+		t.Globals, _ = globals["Function"].(map[string]any)
+	}
+	resources, ok := root["Resources"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("Resources is not found in %s", path)
+	}
+	for id, r := range resources {
+		r, ok := r.(map[string]any)
+		if !ok {
+			continue
+		}
+		res := &samResource{LogicalID: id}
+		res.Type, _ = r["Type"].(string)
+		res.Properties, _ = r["Properties"].(map[string]any)
+		res.Condition, _ = r["Condition"].(string)
+		if res.Properties == nil {
+			res.Properties = make(map[string]any)
+		}
+		t.Resources[id] = res
+	}
+	return t, nil
+}
+
+// yamlNodeValue converts the YAML node to a value. Tagged values like `!Ref Foo` are converted to `{"Ref": "Foo"}`.
+func yamlNodeValue(n *yaml.Node) (any, error) {
+	if strings.HasPrefix(n.Tag, "!") && !strings.HasPrefix(n.Tag, "!!") {
+		untagged := *n
+		untagged.Tag = ""
+		if n.Kind == yaml.ScalarNode {
+			untagged.Tag = "!!str"
+		}
+		v, err := yamlNodeValue(&untagged)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimPrefix(n.Tag, "!")
+		switch name {
+		case "Ref", "Condition":
+			return map[string]any{name: v}, nil
+		case "GetAtt":
+			if s, ok := v.(string); ok {
+				res, attr, _ := strings.Cut(s, ".")
+				v = []any{res, attr}
+			}
+		}
+		return map[string]any{"Fn::" + name: v}, nil
+	}
+
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return yamlNodeValue(n.Content[0])
+	case yaml.MappingNode:
+		m := make(map[string]any, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			v, err := yamlNodeValue(n.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			m[n.Content[i].Value] = v
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		s := make([]any, 0, len(n.Content))
+		for _, c := range n.Content {
+			v, err := yamlNodeValue(c)
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, v)
+		}
+		return s, nil
+	case yaml.AliasNode:
+		return yamlNodeValue(n.Alias)
+	default:
+		var v any
+		if err := n.Decode(&v); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		return v, nil
+	}
+}
+
+// functions converts the functions in the template. ids filters the logical IDs.
+func (t *samTemplate) functions(ids []string) ([]*samFunction, error) {
+	for _, id := range ids {
+		r, ok := t.Resources[id]
+		if !ok {
+			return nil, fmt.Errorf("resource %s is not found in the template", id)
+		}
+		if r.Type != samServerlessFunction && r.Type != cfnLambdaFunction {
+			return nil, fmt.Errorf("resource %s is %s, not a function", id, r.Type)
+		}
+	}
+	var functions []*samFunction
+	for _, r := range t.Resources {
+		if r.Type != samServerlessFunction && r.Type != cfnLambdaFunction {
+			continue
+		}
+		if len(ids) > 0 && !isOneOf(r.LogicalID, ids) {
+			continue
+		}
+		functions = append(functions, t.convertFunction(r))
+	}
+	sort.Slice(functions, func(i, j int) bool {
+		return functions[i].LogicalID < functions[j].LogicalID
+	})
+	return functions, nil
+}
+
+// samCopyProperties are properties which have the same structure in the template and function.json.
+var samCopyProperties = []string{
+	"Architectures",
+	"CodeSigningConfigArn",
+	"DeadLetterConfig",
+	"Description",
+	"Environment",
+	"EphemeralStorage",
+	"FileSystemConfigs",
+	"FunctionName",
+	"Handler",
+	"ImageConfig",
+	"KmsKeyArn",
+	"Layers",
+	"LoggingConfig",
+	"MemorySize",
+	"PackageType",
+	"Role",
+	"Runtime",
+	"SnapStart",
+	"Timeout",
+	"TracingConfig",
+	"VpcConfig",
+}
+
+// samEventSources maps SAM event types to event sources of lambroll event generate.
+var samEventSources = map[string]string{
+	"Api":              "apigateway-v1",
+	"HttpApi":          "apigateway-v2",
+	"SQS":              "sqs",
+	"SNS":              "sns",
+	"S3":               "s3",
+	"EventBridgeRule":  "eventbridge",
+	"CloudWatchEvent":  "eventbridge",
+	"Schedule":         "eventbridge",
+	"ScheduleV2":       "eventbridge",
+	"DynamoDB":         "dynamodb",
+	"Kinesis":          "kinesis",
+	"IoTRule":          "",
+	"Cognito":          "",
+	"AlexaSkill":       "",
+	"CloudWatchLogs":   "",
+	"MSK":              "",
+	"MQ":               "",
+	"SelfManagedKafka": "",
+	"DocumentDB":       "",
+}
+
+func (t *samTemplate) convertFunction(r *samResource) *samFunction {
+	f := &samFunction{
+		LogicalID: r.LogicalID,
+		Function:  make(map[string]any),
+	}
+	props := r.Properties
+	if r.Type == samServerlessFunction && t.Globals != nil {
+		props = mergeSAMGlobals(t.Globals, props).(map[string]any)
+	}
+	if r.Condition != "" {
+		f.report("Condition %s is ignored", r.Condition)
+	}
+
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop := props[name]
+		if isOneOf(name, samCopyProperties) {
+			if v, ok := t.resolve(f, name, prop); ok {
+				f.Function[name] = v
+			}
+			continue
+		}
+		switch name {
+		case "Tags":
+			t.convertTags(f, prop)
+		case "Tracing":
+			if v, ok := t.resolve(f, name, prop); ok {
+				f.Function["TracingConfig"] = map[string]any{"Mode": v}
+			}
+		case "DeadLetterQueue":
+			if v, ok := t.resolve(f, name, prop); ok {
+				if m, ok := v.(map[string]any); ok {
+					f.Function["DeadLetterConfig"] = map[string]any{"TargetArn": m["TargetArn"]}
+				}
+			}
+		case "CodeUri":
+			t.convertCodeUri(f, prop)
+		case "Code":
+			if v, ok := t.resolve(f, name, prop); ok {
+				if m, ok := v.(map[string]any); ok {
+					if _, ok := m["ZipFile"]; ok {
+						f.report("Code.ZipFile (inline code) is not supported. put the code into a file and deploy by --src")
+						delete(m, "ZipFile")
+					}
+					if len(m) > 0 {
+						f.Function["Code"] = m
+					}
+				}
+			}
+		case "ImageUri":
+			if v, ok := t.resolve(f, name, prop); ok {
+				f.Function["Code"] = map[string]any{"ImageUri": v}
+				f.Function["PackageType"] = "Image"
+			}
+		case "FunctionUrlConfig":
+			if v, ok := t.resolve(f, name, prop); ok {
+				f.FunctionURL = map[string]any{"Config": v}
+			}
+		case "Events":
+			t.convertEvents(f, prop)
+		case "AutoPublishAlias":
+			f.report("AutoPublishAlias is not a property of function.json. deploy by lambroll deploy --alias=%v", prop)
+		default:
+			f.report("%s is not supported by lambroll", name)
+		}
+	}
+
+	if _, ok := f.Function["FunctionName"]; !ok {
+		f.Function["FunctionName"] = r.LogicalID
+		f.report("FunctionName is not specified. the logical ID %s is used", r.LogicalID)
+	}
+	if _, ok := f.Function["Role"]; !ok {
+		f.Function["Role"] = "arn:aws:iam::{{ must_env `AWS_ACCOUNT_ID` }}:role/YOUR_LAMBDA_ROLE_NAME"
+		f.report("Role is not specified. SAM creates the role, but lambroll does not. set the role ARN")
+	}
+	if _, ok := f.Function["Handler"]; !ok && f.Function["PackageType"] != "Image" {
+		f.report("Handler is not specified")
+	}
+	return f
+}
+
+func (t *samTemplate) convertTags(f *samFunction, prop any) {
+	v, ok := t.resolve(f, "Tags", prop)
+	if !ok {
+		return
+	}
+	tags := make(map[string]any)
+	switch v := v.(type) {
+	case map[string]any: // SAM
+		tags = v
+	case []any: // CloudFormation
+		for _, tag := range v {
+			if tag, ok := tag.(map[string]any); ok {
+				tags[fmt.Sprint(tag["Key"])] = tag["Value"]
+			}
+		}
+	}
+	if len(tags) > 0 {
+		f.Function["Tags"] = tags
+	}
+}
+
+func (t *samTemplate) convertCodeUri(f *samFunction, prop any) {
+	v, ok := t.resolve(f, "CodeUri", prop)
+	if !ok {
+		return
+	}
+	switch v := v.(type) {
+	case string:
+		if bucket, key, ok := strings.Cut(strings.TrimPrefix(v, "s3://"), "/"); ok && strings.HasPrefix(v, "s3://") {
+			f.Function["Code"] = map[string]any{"S3Bucket": bucket, "S3Key": key}
+		} else {
+			f.Src = v
+		}
+	case map[string]any:
+		code := map[string]any{"S3Bucket": v["Bucket"], "S3Key": v["Key"]}
+		if ver, ok := v["Version"]; ok {
+			code["S3ObjectVersion"] = ver
+		}
+		f.Function["Code"] = code
+	}
+}
+
+func (t *samTemplate) convertEvents(f *samFunction, prop any) {
+	events, ok := prop.(map[string]any)
+	if !ok {
+		return
+	}
+	names := make([]string, 0, len(events))
+	for name := range events {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ev, _ := events[name].(map[string]any)
+		typ, _ := ev["Type"].(string)
+		source := samEventSources[typ]
+		if source == "" {
+			f.report("Events.%s: %s event is not supported by lambroll", name, typ)
+			continue
+		}
+		f.report("Events.%s: the trigger of %s event is not managed by lambroll. an example payload is written to events/%s.json", name, typ, name)
+		if f.Events == nil {
+			f.Events = make(map[string]*EventGenerateOption)
+		}
+		f.Events[name] = newSAMEventOption(source, ev["Properties"])
+	}
+}
+
+// newSAMEventOption returns options to generate an example payload for the event.
+// defaults are the same as lambroll event generate.
+func newSAMEventOption(source string, props any) *EventGenerateOption {
+	opt := &EventGenerateOption{
+		Source:      source,
+		Method:      "GET",
+		Path:        "/",
+		Bucket:      "example-bucket",
+		Key:         "test/key",
+		Queue:       "example-queue",
+		Topic:       "example-topic",
+		Table:       "example-table",
+		Stream:      "example-stream",
+		EventSource: "com.example",
+		DetailType:  "example",
+		AccountID:   "123456789012",
+	}
+	p, _ := props.(map[string]any)
+	if method, ok := p["Method"].(string); ok && !strings.EqualFold(method, "any") {
+		opt.Method = strings.ToUpper(method)
+	}
+	if path, ok := p["Path"].(string); ok {
+		opt.Path = path
+	}
+	if pattern, ok := p["Pattern"].(map[string]any); ok {
+		if s, ok := pattern["source"].([]any); ok && len(s) > 0 {
+			opt.EventSource = fmt.Sprint(s[0])
+		}
+		if s, ok := pattern["detail-type"].([]any); ok && len(s) > 0 {
+			opt.DetailType = fmt.Sprint(s[0])
+		}
+	}
+	return opt
+}
+
+// mergeSAMGlobals merges the properties of the function into the globals.
+// Maps are merged, lists are appended and other values are overridden as SAM does.
+func mergeSAMGlobals(global, local any) any {
+	switch g := global.(type) {
+	case map[string]any:
+		l, ok := local.(map[string]any)
+		if !ok {
+			return local
+		}
+		merged := make(map[string]any, len(g)+len(l))
+		for k, v := range g {
+			merged[k] = v
+		}
+		for k, v := range l {
+			if gv, ok := g[k]; ok {
+				merged[k] = mergeSAMGlobals(gv, v)
+			} else {
+				merged[k] = v
+			}
+		}
+		return merged
+	case []any:
+		l, ok := local.([]any)
+		if !ok {
+			return local
+		}
+		return append(append([]any{}, g...), l...)
+	}
+	return local
+}
+
+var samSubVariableRegexp = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// resolve converts intrinsic functions in the value to template functions of lambroll.
+// It reports unsupported intrinsic functions and returns false for them.
+func (t *samTemplate) resolve(f *samFunction, path string, v any) (any, bool) {
+	switch v := v.(type) {
+	case []any:
+		s := make([]any, 0, len(v))
+		for i, e := range v {
+			r, ok := t.resolve(f, fmt.Sprintf("%s[%d]", path, i), e)
+			if !ok {
+				return nil, false
+			}
+			s = append(s, r)
+		}
+		return s, true
+	case map[string]any:
+		if len(v) == 1 {
+			for k, arg := range v {
+				if k == "Ref" || strings.HasPrefix(k, "Fn::") {
+					return t.resolveIntrinsic(f, path, k, arg)
+				}
+			}
+		}
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			r, ok := t.resolve(f, path+"."+k, e)
+			if !ok {
+				return nil, false
+			}
+			m[k] = r
+		}
+		return m, true
+	}
+	return v, true
+}
+
+func (t *samTemplate) resolveIntrinsic(f *samFunction, path, name string, arg any) (any, bool) {
+	switch name {
+	case "Ref":
+		if s, ok := arg.(string); ok {
+			return t.resolveRef(f, path, s)
+		}
+	case "Fn::GetAtt":
+		if s, ok := arg.([]any); ok && len(s) == 2 {
+			ref := fmt.Sprintf("%v.%v", s[0], s[1])
+			env := samEnvName(ref)
+			f.report("%s: !GetAtt %s is replaced by must_env `%s`", path, ref, env)
+			return fmt.Sprintf("{{ must_env `%s` }}", env), true
+		}
+	case "Fn::Sub":
+		var src string
+		vars := map[string]any{}
+		switch a := arg.(type) {
+		case string:
+			src = a
+		case []any:
+			if len(a) == 2 {
+				src, _ = a[0].(string)
+				vars, _ = a[1].(map[string]any)
+			}
+		}
+		ok := true
+		s := samSubVariableRegexp.ReplaceAllStringFunc(src, func(m string) string {
+			name := m[2 : len(m)-1]
+			if strings.HasPrefix(name, "!") {
+				return "${" + name[1:] + "}" // literal
+			}
+			var r any
+			var rok bool
+			if v, exists := vars[name]; exists {
+				r, rok = t.resolve(f, path, v)
+			} else if res, attr, isAttr := strings.Cut(name, "."); isAttr {
+				r, rok = t.resolveIntrinsic(f, path, "Fn::GetAtt", []any{res, attr})
+			} else {
+				r, rok = t.resolveRef(f, path, name)
+			}
+			if !rok {
+				ok = false
+				return m
+			}
+			return fmt.Sprint(r)
+		})
+		return s, ok
+	case "Fn::Join":
+		if a, ok := arg.([]any); ok && len(a) == 2 {
+			sep, _ := a[0].(string)
+			list, ok := t.resolve(f, path, a[1])
+			if l, isList := list.([]any); ok && isList {
+				ss := make([]string, 0, len(l))
+				for _, e := range l {
+					ss = append(ss, fmt.Sprint(e))
+				}
+				return strings.Join(ss, sep), true
+			}
+		}
+	}
+	f.report("%s: %s is not supported. the property is not imported", path, name)
+	return nil, false
+}
+
+// samPseudoParameters maps pseudo parameters to the values.
+var samPseudoParameters = map[string]string{
+	"AWS::AccountId": "{{ must_env `AWS_ACCOUNT_ID` }}",
+	"AWS::Region":    "{{ must_env `AWS_REGION` }}",
+	"AWS::Partition": "aws",
+	"AWS::URLSuffix": "amazonaws.com",
+}
+
+func (t *samTemplate) resolveRef(f *samFunction, path, name string) (any, bool) {
+	if v, ok := samPseudoParameters[name]; ok {
+		return v, true
+	}
+	if strings.HasPrefix(name, "AWS::") {
+		f.report("%s: pseudo parameter %s is not supported", path, name)
+		return nil, false
+	}
+	if p, ok := t.Parameters[name]; ok {
+		def, hasDefault := p["Default"]
+		if typ, _ := p["Type"].(string); typ == "Number" && hasDefault {
+			f.report("%s: parameter %s (Number) is replaced by the default value %v", path, name, def)
+			return def, true
+		}
+		if hasDefault {
+			return fmt.Sprintf("{{ env `%s` `%v` }}", name, def), true
+		}
+		return fmt.Sprintf("{{ must_env `%s` }}", name), true
+	}
+	env := samEnvName(name)
+	f.report("%s: !Ref %s is replaced by must_env `%s`", path, name, env)
+	return fmt.Sprintf("{{ must_env `%s` }}", env), true
+}
+
+// samEnvName returns an environment variable name for the reference. e.g. MyRole.Arn -> MyRole_Arn
+func samEnvName(ref string) string {
+	return strings.ReplaceAll(ref, ".", "_")
+}
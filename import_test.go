@@ -0,0 +1,154 @@
+package lambroll
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestImportSAMTemplate(t *testing.T) {
+	tmpl, err := loadSAMTemplate("test/sam/template.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	functions, err := tmpl.functions(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(functions) != 2 {
+		t.Fatalf("unexpected number of functions %d", len(functions))
+	}
+
+	hello := functions[0]
+	if hello.LogicalID != "HelloFunction" {
+		t.Fatalf("unexpected function %s", hello.LogicalID)
+	}
+	expected := map[string]any{
+		"FunctionName": "hello-{{ env `Env` `dev` }}",
+		"Handler":      "index.handler",
+		"Runtime":      "nodejs20.x",
+		"Timeout":      10,
+		"MemorySize":   256,
+		"Role":         "{{ must_env `HelloRole_Arn` }}",
+		"TracingConfig": map[string]any{
+			"Mode": "Active",
+		},
+		"Environment": map[string]any{
+			"Variables": map[string]any{
+				"ENV":       "{{ env `Env` `dev` }}",
+				"QUEUE_URL": "{{ must_env `QueueUrl` }}",
+			},
+		},
+		"Layers": []any{
+			"arn:aws:lambda:{{ must_env `AWS_REGION` }}:{{ must_env `AWS_ACCOUNT_ID` }}:layer:common:1",
+			"arn:aws:lambda:us-east-1:123456789012:layer:hello:2",
+		},
+		"VpcConfig": map[string]any{
+			"SubnetIds":        []any{"subnet-1234"},
+			"SecurityGroupIds": []any{"sg-1234"},
+		},
+		"Tags": map[string]any{"Project": "hello"},
+	}
+	if diff := cmp.Diff(expected, hello.Function); diff != "" {
+		t.Error(diff)
+	}
+	if hello.Src != "hello/" {
+		t.Errorf("unexpected src %s", hello.Src)
+	}
+	if diff := cmp.Diff(map[string]any{"Config": map[string]any{"AuthType": "NONE"}}, hello.FunctionURL); diff != "" {
+		t.Error(diff)
+	}
+	if len(hello.Events) != 2 {
+		t.Errorf("unexpected events %v", hello.Events)
+	}
+	if e := hello.Events["GetHello"]; e == nil || e.Source != "apigateway-v1" || e.Method != "POST" || e.Path != "/hello" {
+		t.Errorf("unexpected event %#v", e)
+	}
+	if e := hello.Events["Queue"]; e == nil || e.Source != "sqs" {
+		t.Errorf("unexpected event %#v", e)
+	}
+	assertProblems(t, hello.Problems, "AutoPublishAlias", "Policies is not supported", "AlexaSkill event is not supported", "HelloRole.Arn")
+
+	worker := functions[1]
+	expected = map[string]any{
+		"FunctionName": "worker",
+		"Handler":      "app.handler",
+		"Runtime":      "python3.12",
+		"Role":         "arn:aws:iam::123456789012:role/worker",
+		"Code": map[string]any{
+			"S3Bucket": "my-bucket",
+			"S3Key":    "worker.zip",
+		},
+		"Tags": map[string]any{"Project": "worker"},
+	}
+	if diff := cmp.Diff(expected, worker.Function); diff != "" {
+		t.Error(diff)
+	}
+	assertProblems(t, worker.Problems, "ReservedConcurrentExecutions is not supported", "Description: Fn::If is not supported")
+}
+
+func assertProblems(t *testing.T, problems []string, expected ...string) {
+	t.Helper()
+	for _, e := range expected {
+		found := false
+		for _, p := range problems {
+			if strings.Contains(p, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("problem %q is not reported in %v", e, problems)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	app := &App{}
+	opt := &ImportOption{
+		FromSAM:  "test/sam/template.yaml",
+		Resource: []string{"HelloFunction"},
+		Dir:      dir,
+	}
+	if err := app.Import(context.Background(), opt); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"function.json", "function_url.json", "events/GetHello.json", "events/Queue.json"} {
+		if _, err := os.Stat(filepath.Join(dir, "HelloFunction", name)); err != nil {
+			t.Errorf("%s is not created: %s", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "WorkerFunction")); err == nil {
+		t.Error("WorkerFunction must not be imported")
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, ProjectManifestFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest ProjectManifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Functions) != 1 {
+		t.Fatalf("unexpected manifest %s", string(b))
+	}
+	pf := manifest.Functions[0]
+	if pf.FunctionName != "hello-{{ env `Env` `dev` }}" || pf.Function != "HelloFunction/function.json" || pf.FunctionURL != "HelloFunction/function_url.json" {
+		t.Errorf("unexpected manifest %s", string(b))
+	}
+	wd, _ := os.Getwd()
+	if src, _ := filepath.Rel(wd, filepath.Join(dir, pf.Src)); src != filepath.Join("test", "sam", "hello") {
+		t.Errorf("unexpected src %s", pf.Src)
+	}
+
+	if err := app.Import(context.Background(), &ImportOption{FromSAM: opt.FromSAM, Resource: []string{"Queue"}, Dir: dir}); err == nil {
+		t.Error("expected error for a non-function resource")
+	}
+}
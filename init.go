@@ -8,6 +8,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -17,17 +18,123 @@ import (
 
 // InitOption represents options for Init()
 type InitOption struct {
-	FunctionName *string `help:"Function name for init" required:"true" default:""`
+	FunctionName *string `help:"Function name for init" default:""`
 	DownloadZip  bool    `name:"download" help:"Download function.zip" default:"false"`
+	Extract      bool    `help:"download the code and extract it into --src directory" default:"false"`
+	Src          string  `help:"directory to extract the code by --extract" default:"src"`
 	Jsonnet      bool    `default:"false" help:"render function.json as jsonnet"`
 	Qualifier    *string `help:"function version or alias"`
 	FunctionURL  bool    `help:"create function url definition file" default:"false"`
+	All          bool    `help:"init all functions in the account into a directory for each function" default:"false"`
+	Filter       string  `help:"init functions which match the filter into a directory for each function. function name glob pattern or tag KEY=VALUE" default:""`
+	Dir          string  `help:"base directory for --all and --filter" default:"."`
+
+	Templatize        bool     `help:"replace the account ID, region and values in tfstate or SSM parameters with template functions or Jsonnet ext vars" default:"false"`
+	TemplatizeSSMPath []string `name:"templatize-ssm-path" help:"SSM parameter paths to find values for --templatize"`
+}
+
+// ProjectManifestFilename defines file name of the project manifest created by init --all or --filter.
+const ProjectManifestFilename = "lambroll.json"
+
+// ProjectManifest represents functions managed in the project.
+type ProjectManifest struct {
+	Functions []*ProjectFunction `json:"Functions"`
+}
+
+// ProjectFunction represents a function in the project.
+type ProjectFunction struct {
+	FunctionName string `json:"FunctionName"`
+	Dir          string `json:"Dir"`
+	Function     string `json:"Function"`
+	FunctionURL  string `json:"FunctionURL,omitempty"`
+	Src          string `json:"Src,omitempty"`
 }
 
 // Init initializes function.json
 func (app *App) Init(ctx context.Context, opt *InitOption) error {
+	if !opt.All && opt.Filter == "" && aws.ToString(opt.FunctionName) == "" {
+		return fmt.Errorf("--function-name, --all or --filter is required")
+	}
+	var tz *templatizer
+	if opt.Templatize {
+		var err error
+		if tz, err = app.newTemplatizer(ctx, opt.TemplatizeSSMPath); err != nil {
+			return fmt.Errorf("failed to prepare templatize: %w", err)
+		}
+	}
+
+	var err error
+	if opt.All || opt.Filter != "" {
+		err = app.initAll(ctx, opt, tz)
+	} else {
+		_, err = app.initFunction(ctx, *opt.FunctionName, "", opt, tz)
+	}
+	if err != nil {
+		return err
+	}
+	if vars := tz.extVars(); len(vars) > 0 {
+		if opt.Jsonnet {
+			log.Printf("[info] the definitions use ext vars %s. pass them by --ext-str (e.g. --ext-str %s=...)", strings.Join(vars, ", "), vars[0])
+		} else {
+			log.Printf("[info] the definitions use environment variables %s by must_env. set them to use the definitions (e.g. export %s=...)", strings.Join(vars, ", "), vars[0])
+		}
+	}
+	return nil
+}
+
+// initAll initializes definitions of the functions which match the filter in each directory.
+func (app *App) initAll(ctx context.Context, opt *InitOption, tz *templatizer) error {
+	lopt := &ListOption{Concurrency: 1}
+	if opt.Filter != "" {
+		if strings.Contains(opt.Filter, "=") {
+			lopt.Tag = []string{opt.Filter}
+		} else {
+			lopt.Name = opt.Filter
+		}
+	}
+	functions, err := app.listFunctions(ctx, lopt)
+	if err != nil {
+		return err
+	}
+	if len(functions) == 0 {
+		log.Printf("[warn] no functions found")
+		return nil
+	}
+	log.Printf("[info] %d functions found", len(functions))
+
+	manifest := &ProjectManifest{}
+	for _, f := range functions {
+		name := aws.ToString(f.config.FunctionName)
+		dir := filepath.Join(opt.Dir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+		pf, err := app.initFunction(ctx, name, dir, opt, tz)
+		if err != nil {
+			return fmt.Errorf("failed to init %s: %w", name, err)
+		}
+		// paths in the manifest are relative to the manifest
+		pf.Dir = name
+		pf.Function, _ = filepath.Rel(opt.Dir, pf.Function)
+		if pf.FunctionURL != "" {
+			pf.FunctionURL, _ = filepath.Rel(opt.Dir, pf.FunctionURL)
+		}
+		if pf.Src != "" {
+			pf.Src, _ = filepath.Rel(opt.Dir, pf.Src)
+		}
+		manifest.Functions = append(manifest.Functions, pf)
+	}
+
+	path := filepath.Join(opt.Dir, ProjectManifestFilename)
+	log.Printf("[info] creating %s", path)
+	b, _ := marshalJSON(manifest)
+	return app.saveFile(path, b, os.FileMode(0644))
+}
+
+// initFunction initializes the definition files of the function in dir.
+func (app *App) initFunction(ctx context.Context, functionName string, dir string, opt *InitOption, tz *templatizer) (*ProjectFunction, error) {
 	res, err := app.lambda.GetFunction(ctx, &lambda.GetFunctionInput{
-		FunctionName: opt.FunctionName,
+		FunctionName: aws.String(functionName),
 		Qualifier:    opt.Qualifier,
 	})
 	var c *types.FunctionConfiguration
@@ -35,9 +142,9 @@ func (app *App) Init(ctx context.Context, opt *InitOption) error {
 	if err != nil {
 		var nfe *types.ResourceNotFoundException
 		if errors.As(err, &nfe) {
-			log.Printf("[info] function %s is not found", *opt.FunctionName)
+			log.Printf("[info] function %s is not found", functionName)
 			c = &types.FunctionConfiguration{
-				FunctionName: opt.FunctionName,
+				FunctionName: aws.String(functionName),
 				MemorySize:   aws.Int32(128),
 				Runtime:      types.RuntimeNodejs18x,
 				Timeout:      aws.Int32(3),
@@ -52,10 +159,10 @@ func (app *App) Init(ctx context.Context, opt *InitOption) error {
 			exists = false
 		}
 		if c == nil {
-			return fmt.Errorf("failed to GetFunction %s: %w", *opt.FunctionName, err)
+			return nil, fmt.Errorf("failed to GetFunction %s: %w", functionName, err)
 		}
 	} else {
-		log.Printf("[info] function %s found", *opt.FunctionName)
+		log.Printf("[info] function %s found", functionName)
 		c = res.Configuration
 	}
 
@@ -67,7 +174,7 @@ func (app *App) Init(ctx context.Context, opt *InitOption) error {
 			Resource: aws.String(arn), // tags are not supported for alias
 		})
 		if err != nil {
-			return fmt.Errorf("failed to list tags: %w", err)
+			return nil, fmt.Errorf("failed to list tags: %w", err)
 		}
 		tags = res.Tags
 	}
@@ -78,21 +185,42 @@ func (app *App) Init(ctx context.Context, opt *InitOption) error {
 	}
 	fn := newFunctionFrom(c, code, tags)
 
-	if opt.DownloadZip && res.Code != nil && *res.Code.RepositoryType == "S3" {
-		log.Printf("[info] downloading %s", FunctionZipFilename)
-		if err := download(*res.Code.Location, FunctionZipFilename); err != nil {
-			return err
+	var srcDir string
+	if (opt.DownloadZip || opt.Extract) && code != nil {
+		switch aws.ToString(code.RepositoryType) {
+		case "S3":
+			if opt.DownloadZip {
+				path := filepath.Join(dir, FunctionZipFilename)
+				log.Printf("[info] downloading %s", path)
+				if err := download(*code.Location, path); err != nil {
+					return nil, err
+				}
+			}
+			if opt.Extract {
+				srcDir = filepath.Join(dir, opt.Src)
+				if err := extractFunctionCode(*code.Location, srcDir); err != nil {
+					return nil, err
+				}
+			}
+		case "ECR":
+			ref := aws.ToString(code.ResolvedImageUri)
+			if ref == "" {
+				ref = aws.ToString(code.ImageUri)
+			}
+			log.Printf("[info] %s is an Image function. the code can be pulled by the image reference", functionName)
+			fmt.Println(ref)
 		}
 	}
 
-	log.Printf("[info] creating %s", IgnoreFilename)
+	ignorePath := filepath.Join(dir, IgnoreFilename)
+	log.Printf("[info] creating %s", ignorePath)
 	err = app.saveFile(
-		IgnoreFilename,
+		ignorePath,
 		[]byte(strings.Join(DefaultExcludes, "\n")+"\n"),
 		os.FileMode(0644),
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var name string
@@ -101,37 +229,69 @@ func (app *App) Init(ctx context.Context, opt *InitOption) error {
 	} else {
 		name = DefaultFunctionFilenames[0]
 	}
-	log.Printf("[info] creating %s", name)
+	pf := &ProjectFunction{
+		FunctionName: functionName,
+		Dir:          dir,
+		Function:     filepath.Join(dir, name),
+		Src:          srcDir,
+	}
+	log.Printf("[info] creating %s", pf.Function)
 	b, _ := marshalJSON(fn)
+	b = tz.templatize(b, opt.Jsonnet)
 	if opt.Jsonnet {
 		b, err = jsonToJsonnet(b, name)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
-	if err := app.saveFile(name, b, os.FileMode(0644)); err != nil {
-		return err
+	if err := app.saveFile(pf.Function, b, os.FileMode(0644)); err != nil {
+		return nil, err
 	}
 
 	if opt.FunctionURL {
-		if err := app.initFunctionURL(ctx, fn, exists, opt); err != nil {
-			return err
+		if pf.FunctionURL, err = app.initFunctionURL(ctx, fn, exists, dir, opt, tz); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	return pf, nil
+}
+
+// extractFunctionCode downloads the code from url and extracts it into dir.
+func extractFunctionCode(url, dir string) error {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s already exists and is not empty", dir)
+	}
+	tmp, err := os.CreateTemp("", FunctionZipFilename)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err := download(url, tmp.Name()); err != nil {
+		return err
+	}
+	log.Printf("[info] extracting the code into %s", dir)
+	return extractZipArchive(tmp.Name(), dir)
 }
 
 func download(url, path string) error {
 	resp, err := http.Get(url)
 	if err != nil {
-		return fmt.Errorf("failed to get %s: %w", url, err)
+		// url is a presigned URL. don't show it
+		return fmt.Errorf("failed to download %s: %w", path, errors.Unwrap(err))
 	}
 	defer resp.Body.Close()
-	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, os.FileMode(0644))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: unexpected HTTP status %s", path, resp.Status)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(0644))
 	if err != nil {
 		return fmt.Errorf("failed to open file %s: %w", path, err)
 	}
-	_, err = io.Copy(f, resp.Body)
-	return err
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to download %s: %w", path, err)
+	}
+	return f.Close()
 }
@@ -0,0 +1,143 @@
+package lambroll
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/google/go-cmp/cmp"
+)
+
+func newFakeInitServer(t *testing.T) *httptest.Server {
+	list := newFakeListFunctionsServer(t)
+	t.Cleanup(list.Close)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// GetFunction: /2015-03-31/functions/{name}
+		if name, ok := strings.CutPrefix(r.URL.Path, "/2015-03-31/functions/"); ok && name != "" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{
+				"Configuration": map[string]any{
+					"FunctionName": name,
+					"FunctionArn":  "arn:aws:lambda:us-east-1:123456789012:function:" + name,
+					"Runtime":      "nodejs20.x",
+					"Handler":      "index.handler",
+					"Role":         "arn:aws:iam::123456789012:role/lambda",
+					"MemorySize":   128,
+					"Timeout":      3,
+				},
+			})
+			return
+		}
+		// ListFunctions and ListTags
+		req, _ := http.NewRequest(r.Method, list.URL+r.URL.RequestURI(), r.Body)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.StatusCode)
+		var v any
+		json.NewDecoder(resp.Body).Decode(&v)
+		json.NewEncoder(w).Encode(v)
+	}))
+}
+
+func TestInitAll(t *testing.T) {
+	ts := newFakeInitServer(t)
+	defer ts.Close()
+	app := &App{
+		accountID: "123456789012",
+		awsConfig: aws.Config{Region: "us-east-1"},
+		lambda: lambda.New(lambda.Options{
+			Region:       "us-east-1",
+			BaseEndpoint: aws.String(ts.URL),
+			Credentials:  aws.AnonymousCredentials{},
+		}),
+	}
+
+	dir := t.TempDir()
+	opt := &InitOption{Filter: "Env=prod", Dir: dir}
+	if err := app.Init(context.Background(), opt); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"app-a/function.json", "app-a/.lambdaignore", "app-c/function.json"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			t.Errorf("%s is not created: %s", f, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app-b")); err == nil {
+		t.Error("app-b should not be created")
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, ProjectManifestFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest ProjectManifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	expected := ProjectManifest{
+		Functions: []*ProjectFunction{
+			{FunctionName: "app-a", Dir: "app-a", Function: "app-a/function.json"},
+			{FunctionName: "app-c", Dir: "app-c", Function: "app-c/function.json"},
+		},
+	}
+	if diff := cmp.Diff(expected, manifest); diff != "" {
+		t.Error(diff)
+	}
+
+	b, err = os.ReadFile(filepath.Join(dir, "app-c", "function.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fn Function
+	if err := json.Unmarshal(b, &fn); err != nil {
+		t.Fatal(err)
+	}
+	if aws.ToString(fn.FunctionName) != "app-c" || fn.Tags["Env"] != "prod" {
+		t.Errorf("unexpected function.json %s", string(b))
+	}
+}
+
+func TestInitRequiresFunctionName(t *testing.T) {
+	app := &App{}
+	if err := app.Init(context.Background(), &InitOption{FunctionName: aws.String("")}); err == nil {
+		t.Error("expected error without --function-name")
+	}
+}
+
+func TestDownload(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/function.zip" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("<Error>AccessDenied</Error>"))
+			return
+		}
+		w.Write([]byte("new"))
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), FunctionZipFilename)
+	if err := os.WriteFile(path, []byte("old content which is longer"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := download(ts.URL+"/function.zip", path); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "new" {
+		t.Errorf("the file must be truncated: %s", string(b))
+	}
+	if err := download(ts.URL+"/expired", path); err == nil {
+		t.Error("expected error for HTTP 403")
+	}
+}
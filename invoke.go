@@ -23,23 +23,70 @@ type InvokeOption struct {
 	LogTail   bool    `default:"false" help:"output tail of log to STDERR"`
 	Qualifier *string `help:"version or alias to invoke"`
 	Payload   *string `help:"payload to invoke. if not specified, read from STDIN"`
+	Local     bool    `default:"false" help:"invoke the function on the local machine by the bootstrap in --src via the Lambda Runtime API"`
+	Src       string  `default:"." help:"function src dir for --local"`
+	Expect    string  `default:"" help:"path to expectations file for the responses. exit with non-zero status when the expectations are not satisfied"`
+	Stream    bool    `default:"false" help:"invoke the function by InvokeWithResponseStream API and write the response chunks as they arrive"`
+
+	InvokeLoadOption
+	InvokeURLOption
 }
 
+// invokeFunc invokes the function with the payload.
+type invokeFunc func(ctx context.Context, payload []byte) (*lambda.InvokeOutput, error)
+
 // Invoke invokes function
 func (app *App) Invoke(ctx context.Context, opt *InvokeOption) error {
 	fn, err := app.loadFunction(app.functionFilePath)
 	if err != nil {
 		return fmt.Errorf("failed to load function: %w", err)
 	}
-	var invocationType typesv2.InvocationType
-	var logType typesv2.LogType
-	if opt.Async {
-		invocationType = typesv2.InvocationTypeEvent
-	} else {
-		invocationType = typesv2.InvocationTypeRequestResponse
+
+	var expectations InvokeExpectations
+	if opt.Expect != "" {
+		if opt.InvokeLoadOption.enabled() {
+			return fmt.Errorf("--expect cannot be used with --concurrency, --count or --rate")
+		}
+		if expectations, err = app.loadInvokeExpectations(opt.Expect); err != nil {
+			return fmt.Errorf("failed to load expectations: %w", err)
+		}
 	}
-	if opt.LogTail {
-		logType = typesv2.LogTypeTail
+
+	if opt.Stream {
+		switch {
+		case opt.Async:
+			return fmt.Errorf("--stream cannot be used with --async")
+		case opt.Local:
+			return fmt.Errorf("--stream cannot be used with --local")
+		case opt.ViaURL:
+			return fmt.Errorf("--stream cannot be used with --via-url. --via-url always streams the response")
+		}
+	}
+
+	if opt.ViaURL {
+		if opt.Local {
+			return fmt.Errorf("--via-url cannot be used with --local")
+		}
+		return app.invokeViaURL(ctx, fn, opt, expectations)
+	}
+
+	stdout := bufio.NewWriter(os.Stdout)
+	stderr := bufio.NewWriter(os.Stderr)
+	var invoke invokeFunc
+	switch {
+	case opt.Stream && opt.InvokeLoadOption.enabled():
+		invoke = app.invokeStreamFunc(fn, opt, io.Discard)
+	case opt.Stream:
+		invoke = app.invokeStreamFunc(fn, opt, stdout)
+	case opt.Local:
+		rt, err := app.startLocalRuntime(ctx, fn, opt.Src)
+		if err != nil {
+			return fmt.Errorf("failed to start local runtime: %w", err)
+		}
+		defer rt.Close()
+		invoke = rt.Invoke
+	default:
+		invoke = app.invokeFunc(fn, opt)
 	}
 
 	var payloadSrc io.Reader
@@ -52,10 +99,30 @@ func (app *App) Invoke(ctx context.Context, opt *InvokeOption) error {
 		payloadSrc = os.Stdin
 	}
 	dec := json.NewDecoder(payloadSrc)
-	stdout := bufio.NewWriter(os.Stdout)
-	stderr := bufio.NewWriter(os.Stderr)
+
+	if opt.InvokeLoadOption.enabled() {
+		var payloads [][]byte
+		for {
+			var payload interface{}
+			if err := dec.Decode(&payload); err != nil {
+				if err == io.EOF {
+					break
+				}
+				return fmt.Errorf("failed to decode payload as JSON: %w", err)
+			}
+			b, _ := json.Marshal(payload)
+			payloads = append(payloads, b)
+		}
+		summary, err := invokeLoad(ctx, invoke, payloads, opt.InvokeLoadOption)
+		if summary != nil {
+			fmt.Print(summary.String())
+		}
+		return err
+	}
+
+	var invoked, unsatisfied int
 PAYLOAD:
-	for {
+	for n := 0; ; n++ {
 		var payload interface{}
 		err := dec.Decode(&payload)
 		if err != nil {
@@ -65,24 +132,28 @@ PAYLOAD:
 			return fmt.Errorf("failed to decode payload as JSON: %w", err)
 		}
 		b, _ := json.Marshal(payload)
-		in := &lambda.InvokeInput{
-			FunctionName:   fn.FunctionName,
-			InvocationType: invocationType,
-			LogType:        logType,
-			Payload:        b,
-		}
-		in.Qualifier = opt.Qualifier
-		log.Println("[debug] invoking function", in)
-		res, err := app.lambda.Invoke(ctx, in)
+		invoked++
+		res, err := invoke(ctx, b)
 		if err != nil {
 			log.Println("[error] failed to invoke function", err.Error())
+			// the failure does not satisfy the expectation for the payload if exists
+			if expectations.For(n) != nil {
+				log.Printf("[error] payload[%d] expectations are not satisfied by the failed invocation", n)
+				unsatisfied++
+			}
 			continue PAYLOAD
 		}
-		stdout.Write(res.Payload)
+		if !opt.Stream {
+			// streamed payload has already been written
+			stdout.Write(res.Payload)
+		}
 		stdout.Write([]byte("\n"))
 		stdout.Flush()
 
 		log.Printf("[info] StatusCode:%d", res.StatusCode)
+		if res.FunctionError != nil {
+			log.Printf("[warn] FunctionError:%s", *res.FunctionError)
+		}
 		if res.ExecutedVersion != nil {
 			log.Printf("[info] ExecutionVersion:%s", *res.ExecutedVersion)
 		}
@@ -91,7 +162,44 @@ PAYLOAD:
 			stderr.Write(b)
 			stderr.Flush()
 		}
+		if e := expectations.For(n); e != nil {
+			if failures := e.Check(res); len(failures) > 0 {
+				unsatisfied++
+				for _, f := range failures {
+					log.Printf("[error] payload[%d] %s", n, f)
+				}
+			} else {
+				log.Printf("[info] payload[%d] expectations satisfied", n)
+			}
+		}
 	}
 
+	if unsatisfied > 0 {
+		return fmt.Errorf("%d of %d invocations did not satisfy the expectations", unsatisfied, invoked)
+	}
 	return nil
 }
+
+func (app *App) invokeFunc(fn *Function, opt *InvokeOption) invokeFunc {
+	var invocationType typesv2.InvocationType
+	var logType typesv2.LogType
+	if opt.Async {
+		invocationType = typesv2.InvocationTypeEvent
+	} else {
+		invocationType = typesv2.InvocationTypeRequestResponse
+	}
+	if opt.LogTail {
+		logType = typesv2.LogTypeTail
+	}
+	return func(ctx context.Context, payload []byte) (*lambda.InvokeOutput, error) {
+		in := &lambda.InvokeInput{
+			FunctionName:   fn.FunctionName,
+			InvocationType: invocationType,
+			LogType:        logType,
+			Payload:        payload,
+		}
+		in.Qualifier = opt.Qualifier
+		log.Println("[debug] invoking function", in)
+		return app.lambda.Invoke(ctx, in)
+	}
+}
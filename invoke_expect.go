@@ -0,0 +1,119 @@
+package lambroll
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aereal/jsondiff"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/itchyny/gojq"
+)
+
+// InvokeExpectation represents an expectation for the response of an invocation.
+type InvokeExpectation struct {
+	// StatusCode is the expected status code. Not checked when omitted.
+	StatusCode *int32 `json:"StatusCode,omitempty"`
+	// FunctionError is the expected function error. No function error is expected when omitted.
+	FunctionError *string `json:"FunctionError,omitempty"`
+	// Payload is the expected response payload. Not checked when omitted.
+	Payload any `json:"Payload,omitempty"`
+	// Assertions are jq expressions which must be evaluated to true for the response payload.
+	Assertions []string `json:"Assertions,omitempty"`
+
+	queries []*gojq.Code
+}
+
+// InvokeExpectations represents expectations for the payloads in order.
+// When only one expectation is defined, it is applied to all payloads.
+type InvokeExpectations []*InvokeExpectation
+
+func (es InvokeExpectations) compile() error {
+	for i, e := range es {
+		for _, a := range e.Assertions {
+			q, err := gojq.Parse(a)
+			if err != nil {
+				return fmt.Errorf("failed to parse assertion [%d] %s: %w", i, a, err)
+			}
+			code, err := gojq.Compile(q)
+			if err != nil {
+				return fmt.Errorf("failed to compile assertion [%d] %s: %w", i, a, err)
+			}
+			e.queries = append(e.queries, code)
+		}
+	}
+	return nil
+}
+
+// For returns the expectation for the n-th (0 origin) payload.
+func (es InvokeExpectations) For(n int) *InvokeExpectation {
+	if len(es) == 1 {
+		return es[0]
+	}
+	if n < len(es) {
+		return es[n]
+	}
+	return nil
+}
+
+func (app *App) loadInvokeExpectations(path string) (InvokeExpectations, error) {
+	es, err := loadDefinitionFile[InvokeExpectations](app, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := es.compile(); err != nil {
+		return nil, err
+	}
+	return *es, nil
+}
+
+// Check checks the response and returns readable failure messages.
+func (e *InvokeExpectation) Check(res *lambda.InvokeOutput) []string {
+	var failures []string
+	if e.StatusCode != nil && *e.StatusCode != res.StatusCode {
+		failures = append(failures, fmt.Sprintf("StatusCode expected %d, got %d", *e.StatusCode, res.StatusCode))
+	}
+	if expected, got := aws.ToString(e.FunctionError), aws.ToString(res.FunctionError); expected != got {
+		if expected == "" {
+			failures = append(failures, fmt.Sprintf("FunctionError expected none, got %s", got))
+		} else {
+			failures = append(failures, fmt.Sprintf("FunctionError expected %s, got %q", expected, got))
+		}
+	}
+	if e.Payload == nil && len(e.queries) == 0 {
+		return failures
+	}
+
+	var payload any
+	if err := json.Unmarshal(res.Payload, &payload); err != nil {
+		return append(failures, fmt.Sprintf("response payload is not a JSON: %s", err))
+	}
+	if e.Payload != nil {
+		if ds, err := jsondiff.Diff(
+			&jsondiff.Input{Name: "expected", X: e.Payload},
+			&jsondiff.Input{Name: "response", X: payload},
+		); err != nil {
+			failures = append(failures, fmt.Sprintf("failed to diff payload: %s", err))
+		} else if ds != "" {
+			failures = append(failures, "Payload mismatch:\n"+coloredDiff(ds))
+		}
+	}
+	for i, q := range e.queries {
+		iter := q.Run(payload)
+		v, ok := iter.Next()
+		switch {
+		case !ok:
+			failures = append(failures, fmt.Sprintf("assertion %s returned no result", e.Assertions[i]))
+		case v == true:
+			// passed
+		default:
+			if err, isErr := v.(error); isErr {
+				failures = append(failures, fmt.Sprintf("assertion %s failed: %s", e.Assertions[i], err))
+			} else {
+				b, _ := json.Marshal(v)
+				failures = append(failures, fmt.Sprintf("assertion %s failed: got %s", e.Assertions[i], string(b)))
+			}
+		}
+	}
+	return failures
+}
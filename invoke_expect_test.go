@@ -0,0 +1,55 @@
+package lambroll
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/kayac/go-config"
+)
+
+func TestInvokeExpectations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "expect.json")
+	os.WriteFile(path, []byte(`[
+  {
+    "StatusCode": 200,
+    "Assertions": [".statusCode == 200", "(.body | fromjson | .ok) == true"]
+  },
+  {
+    "StatusCode": 200,
+    "FunctionError": "Unhandled",
+    "Payload": {"errorMessage": "oops"}
+  }
+]`), 0644)
+	app := &App{loader: config.New()}
+	es, err := app.loadInvokeExpectations(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(es) != 2 || es.For(2) != nil {
+		t.Fatalf("unexpected expectations %v", es)
+	}
+
+	ok := &lambda.InvokeOutput{StatusCode: 200, Payload: []byte(`{"statusCode":200,"body":"{\"ok\":true}"}`)}
+	if failures := es.For(0).Check(ok); len(failures) != 0 {
+		t.Errorf("unexpected failures %v", failures)
+	}
+	ng := &lambda.InvokeOutput{StatusCode: 200, Payload: []byte(`{"statusCode":500,"body":"{\"ok\":false}"}`)}
+	if failures := es.For(0).Check(ng); len(failures) != 2 {
+		t.Errorf("unexpected failures %v", failures)
+	}
+	fe := &lambda.InvokeOutput{StatusCode: 200, FunctionError: aws.String("Unhandled"), Payload: []byte(`{"errorMessage":"oops"}`)}
+	if failures := es.For(0).Check(fe); len(failures) == 0 || !strings.Contains(failures[0], "FunctionError expected none") {
+		t.Errorf("unexpected failures %v", failures)
+	}
+	if failures := es.For(1).Check(fe); len(failures) != 0 {
+		t.Errorf("unexpected failures %v", failures)
+	}
+	fe.Payload = []byte(`{"errorMessage":"bad"}`)
+	if failures := es.For(1).Check(fe); len(failures) != 1 || !strings.Contains(failures[0], "Payload mismatch") {
+		t.Errorf("unexpected failures %v", failures)
+	}
+}
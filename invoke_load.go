@@ -0,0 +1,167 @@
+package lambroll
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/olekukonko/tablewriter"
+)
+
+// InvokeLoadOption represents options for invoking the function concurrently
+type InvokeLoadOption struct {
+	Concurrency int     `default:"1" help:"number of concurrent invocations"`
+	Count       int     `default:"0" help:"total number of invocations. payloads are used in rotation (default: number of payloads)"`
+	Rate        float64 `default:"0" help:"max invocations per second (default: unlimited)"`
+}
+
+func (opt *InvokeLoadOption) enabled() bool {
+	return opt.Concurrency > 1 || opt.Count > 0 || opt.Rate > 0
+}
+
+// InvokeSummary represents a summary of concurrent invocations.
+type InvokeSummary struct {
+	Invocations      int            `json:"Invocations"`
+	Failures         int            `json:"Failures"`
+	Elapsed          time.Duration  `json:"Elapsed"`
+	StatusCodes      map[int32]int  `json:"StatusCodes"`
+	FunctionErrors   map[string]int `json:"FunctionErrors"`
+	ExecutedVersions map[string]int `json:"ExecutedVersions"`
+	LatencyP50       time.Duration  `json:"LatencyP50"`
+	LatencyP90       time.Duration  `json:"LatencyP90"`
+	LatencyP99       time.Duration  `json:"LatencyP99"`
+	LatencyMax       time.Duration  `json:"LatencyMax"`
+
+	latencies []float64
+}
+
+func (s *InvokeSummary) String() string {
+	buf := new(strings.Builder)
+	w := tablewriter.NewWriter(buf)
+	w.Append([]string{"Invocations", strconv.Itoa(s.Invocations)})
+	w.Append([]string{"Failures", strconv.Itoa(s.Failures)})
+	w.Append([]string{"Elapsed", s.Elapsed.Round(time.Millisecond).String()})
+	if s.Elapsed > 0 {
+		w.Append([]string{"Throughput", fmt.Sprintf("%.2f/s", float64(s.Invocations)/s.Elapsed.Seconds())})
+	}
+	for _, k := range sortedKeys(s.StatusCodes) {
+		w.Append([]string{"StatusCode " + strconv.Itoa(int(k)), strconv.Itoa(s.StatusCodes[k])})
+	}
+	for _, k := range sortedKeys(s.FunctionErrors) {
+		w.Append([]string{"FunctionError " + k, strconv.Itoa(s.FunctionErrors[k])})
+	}
+	for _, k := range sortedKeys(s.ExecutedVersions) {
+		w.Append([]string{"ExecutedVersion " + k, fmt.Sprintf("%d (%.1f%%)", s.ExecutedVersions[k], float64(s.ExecutedVersions[k])/float64(s.Invocations)*100)})
+	}
+	w.Append([]string{"Latency p50", s.LatencyP50.Round(time.Millisecond).String()})
+	w.Append([]string{"Latency p90", s.LatencyP90.Round(time.Millisecond).String()})
+	w.Append([]string{"Latency p99", s.LatencyP99.Round(time.Millisecond).String()})
+	w.Append([]string{"Latency max", s.LatencyMax.Round(time.Millisecond).String()})
+	w.Render()
+	return buf.String()
+}
+
+func sortedKeys[K int32 | string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+func newInvokeSummary() *InvokeSummary {
+	return &InvokeSummary{
+		StatusCodes:      make(map[int32]int),
+		FunctionErrors:   make(map[string]int),
+		ExecutedVersions: make(map[string]int),
+	}
+}
+
+// invokeLoad invokes the function with the payloads concurrently and returns the summary.
+func invokeLoad(ctx context.Context, invoke invokeFunc, payloads [][]byte, opt InvokeLoadOption) (*InvokeSummary, error) {
+	if len(payloads) == 0 {
+		return nil, fmt.Errorf("no payloads to invoke")
+	}
+	count := opt.Count
+	if count <= 0 {
+		count = len(payloads)
+	}
+	concurrency := opt.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	log.Printf("[info] invoking %d times with concurrency %d", count, concurrency)
+
+	var tick <-chan time.Time
+	if opt.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / opt.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	summary := newInvokeSummary()
+	var mu sync.Mutex
+	queue := make(chan []byte)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for payload := range queue {
+				start := time.Now()
+				res, err := invoke(ctx, payload)
+				latency := time.Since(start)
+				mu.Lock()
+				summary.Invocations++
+				if err != nil {
+					log.Println("[warn] failed to invoke function", err.Error())
+					summary.Failures++
+				} else {
+					summary.StatusCodes[res.StatusCode]++
+					if res.FunctionError != nil {
+						summary.FunctionErrors[*res.FunctionError]++
+					}
+					if v := aws.ToString(res.ExecutedVersion); v != "" {
+						summary.ExecutedVersions[v]++
+					}
+					summary.latencies = append(summary.latencies, float64(latency))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	start := time.Now()
+SEND:
+	for i := 0; i < count; i++ {
+		if tick != nil {
+			select {
+			case <-tick:
+			case <-ctx.Done():
+				break SEND
+			}
+		}
+		select {
+		case queue <- payloads[i%len(payloads)]:
+		case <-ctx.Done():
+			break SEND
+		}
+	}
+	close(queue)
+	wg.Wait()
+	summary.Elapsed = time.Since(start)
+
+	sort.Float64s(summary.latencies)
+	summary.LatencyP50 = time.Duration(percentile(summary.latencies, 50))
+	summary.LatencyP90 = time.Duration(percentile(summary.latencies, 90))
+	summary.LatencyP99 = time.Duration(percentile(summary.latencies, 99))
+	summary.LatencyMax = time.Duration(percentile(summary.latencies, 100))
+	return summary, ctx.Err()
+}
@@ -0,0 +1,47 @@
+package lambroll
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInvokeLoad(t *testing.T) {
+	var n int32
+	invoke := func(ctx context.Context, payload []byte) (*lambda.InvokeOutput, error) {
+		i := atomic.AddInt32(&n, 1)
+		res := &lambda.InvokeOutput{StatusCode: 200, ExecutedVersion: aws.String("1"), Payload: payload}
+		if i%4 == 0 {
+			res.ExecutedVersion = aws.String("2")
+		}
+		if string(payload) == `{"error":true}` {
+			res.FunctionError = aws.String("Unhandled")
+		}
+		return res, nil
+	}
+	payloads := [][]byte{[]byte(`{"foo":1}`), []byte(`{"error":true}`)}
+	summary, err := invokeLoad(context.Background(), invoke, payloads, InvokeLoadOption{Concurrency: 4, Count: 20, Rate: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Invocations != 20 || summary.Failures != 0 {
+		t.Errorf("unexpected invocations %d failures %d", summary.Invocations, summary.Failures)
+	}
+	if d := cmp.Diff(map[int32]int{200: 20}, summary.StatusCodes); d != "" {
+		t.Errorf("status codes mismatch: diff:%s", d)
+	}
+	if d := cmp.Diff(map[string]int{"Unhandled": 10}, summary.FunctionErrors); d != "" {
+		t.Errorf("function errors mismatch: diff:%s", d)
+	}
+	if d := cmp.Diff(map[string]int{"1": 15, "2": 5}, summary.ExecutedVersions); d != "" {
+		t.Errorf("executed versions mismatch: diff:%s", d)
+	}
+	if summary.LatencyMax < summary.LatencyP50 {
+		t.Errorf("unexpected latencies %s %s", summary.LatencyP50, summary.LatencyMax)
+	}
+	t.Log(summary.String())
+}
@@ -0,0 +1,338 @@
+package lambroll
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+const runtimeAPIPrefix = "/2018-06-01/runtime"
+
+// localAccountID is a placeholder of the account ID in the function ARN for local invocations.
+const localAccountID = "000000000000"
+
+// localRuntime emulates the Lambda Runtime API for the bootstrap running on the local machine.
+type localRuntime struct {
+	fn          *Function
+	functionArn string
+	listener    net.Listener
+	server      *http.Server
+	bootstrap   string
+	dir         string
+	env         []string
+
+	next    chan *localInvocation
+	initErr chan []byte
+
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	exited  chan struct{}
+	running map[string]*localInvocation
+	waitErr error
+}
+
+type localInvocation struct {
+	id       string
+	payload  []byte
+	deadline time.Time
+	done     chan *lambda.InvokeOutput
+}
+
+// localBootstrapPath returns the path of the executable to run for the function.
+func localBootstrapPath(fn *Function, src string) (string, error) {
+	if fn.PackageType == types.PackageTypeImage {
+		return "", fmt.Errorf("--local does not support PackageType=Image")
+	}
+	name := "bootstrap"
+	if fn.Runtime == types.RuntimeGo1x {
+		name = aws.ToString(fn.Handler)
+	}
+	path := filepath.Join(src, name)
+	if _, err := os.Stat(path); err != nil {
+		switch fn.Runtime {
+		case types.RuntimeGo1x, types.RuntimeProvided, types.RuntimeProvidedal2, types.RuntimeProvidedal2023:
+			return "", fmt.Errorf("%s is not found in %s: %w", name, src, err)
+		}
+		return "", fmt.Errorf("--local requires an executable bootstrap in %s for runtime %s", src, fn.Runtime)
+	}
+	return filepath.Abs(path)
+}
+
+// localRuntimeEnv returns environment variables for the bootstrap process.
+func localRuntimeEnv(fn *Function, runtimeAPI, taskRoot, region string) []string {
+	env := os.Environ()
+	env = append(env,
+		"AWS_LAMBDA_RUNTIME_API="+runtimeAPI,
+		"AWS_LAMBDA_FUNCTION_NAME="+aws.ToString(fn.FunctionName),
+		"AWS_LAMBDA_FUNCTION_VERSION="+versionLatest,
+		"AWS_LAMBDA_FUNCTION_MEMORY_SIZE="+strconv.Itoa(int(aws.ToInt32(fn.MemorySize))),
+		"AWS_LAMBDA_LOG_GROUP_NAME="+resolveLogGroup(fn),
+		"AWS_REGION="+region,
+		"AWS_DEFAULT_REGION="+region,
+		"LAMBDA_TASK_ROOT="+taskRoot,
+		"_HANDLER="+aws.ToString(fn.Handler),
+	)
+	if fn.Environment != nil {
+		for k, v := range fn.Environment.Variables {
+			env = append(env, k+"="+v)
+		}
+	}
+	return env
+}
+
+func (app *App) startLocalRuntime(ctx context.Context, fn *Function, src string) (*localRuntime, error) {
+	fillDefaultValues(fn)
+	bootstrap, err := localBootstrapPath(fn, src)
+	if err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen runtime API: %w", err)
+	}
+	taskRoot, _ := filepath.Abs(src)
+	region := app.awsConfig.Region
+	rt := &localRuntime{
+		fn: fn,
+		// the local invocation does not need to resolve the account ID by STS
+		functionArn: fmt.Sprintf("arn:aws:lambda:%s:%s:function:%s", region, localAccountID, *fn.FunctionName),
+		listener:    l,
+		bootstrap:   bootstrap,
+		dir:         taskRoot,
+		env:         localRuntimeEnv(fn, l.Addr().String(), taskRoot, region),
+		next:        make(chan *localInvocation),
+		initErr:     make(chan []byte, 1),
+		running:     make(map[string]*localInvocation),
+	}
+	rt.server = &http.Server{Handler: rt}
+	go rt.server.Serve(l)
+
+	log.Printf("[info] starting %s with runtime API %s", bootstrap, l.Addr())
+	if err := rt.start(); err != nil {
+		rt.server.Close()
+		return nil, err
+	}
+	return rt, nil
+}
+
+// start starts the bootstrap process.
+func (rt *localRuntime) start() error {
+	cmd := exec.Command(rt.bootstrap)
+	cmd.Dir = rt.dir
+	cmd.Env = rt.env
+	// logs of the function are written to STDERR
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", rt.bootstrap, err)
+	}
+	exited := make(chan struct{})
+	rt.mu.Lock()
+	rt.cmd = cmd
+	rt.exited = exited
+	rt.mu.Unlock()
+	go func() {
+		err := cmd.Wait()
+		rt.mu.Lock()
+		rt.waitErr = err
+		rt.mu.Unlock()
+		close(exited)
+	}()
+	return nil
+}
+
+// process returns the running bootstrap process and the channel closed at its exit.
+func (rt *localRuntime) process() (*exec.Cmd, chan struct{}) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.cmd, rt.exited
+}
+
+// restart kills the bootstrap process and starts a new one as Lambda does after a timeout.
+// It does nothing when the process has been restarted by another invocation already.
+func (rt *localRuntime) restart(cmd *exec.Cmd, exited chan struct{}) error {
+	if cmd == nil || cmd.Process == nil {
+		return nil
+	}
+	cmd.Process.Kill()
+	<-exited
+	rt.mu.Lock()
+	restarted := rt.cmd != cmd
+	rt.mu.Unlock()
+	if restarted {
+		return nil
+	}
+	log.Printf("[info] restarting %s", rt.bootstrap)
+	return rt.start()
+}
+
+// Close stops the bootstrap process and the runtime API server.
+func (rt *localRuntime) Close() error {
+	if cmd, exited := rt.process(); cmd != nil && cmd.Process != nil {
+		select {
+		case <-exited:
+		default:
+			cmd.Process.Kill()
+			<-exited
+		}
+	}
+	return rt.server.Close()
+}
+
+func (rt *localRuntime) exitError() error {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if rt.waitErr != nil {
+		return fmt.Errorf("bootstrap exited: %w", rt.waitErr)
+	}
+	return errors.New("bootstrap exited")
+}
+
+// Invoke sends the payload to the bootstrap and waits for the response.
+func (rt *localRuntime) Invoke(ctx context.Context, payload []byte) (*lambda.InvokeOutput, error) {
+	timeout := time.Duration(aws.ToInt32(rt.fn.Timeout)) * time.Second
+	inv := &localInvocation{
+		id:       newRequestID(),
+		payload:  payload,
+		deadline: time.Now().Add(timeout),
+		done:     make(chan *lambda.InvokeOutput, 1),
+	}
+	log.Printf("[debug] invoking local function RequestId: %s", inv.id)
+	cmd, exited := rt.process()
+	select {
+	case rt.next <- inv:
+	case b := <-rt.initErr:
+		return &lambda.InvokeOutput{
+			StatusCode:    200,
+			FunctionError: aws.String("Unhandled"),
+			Payload:       b,
+		}, nil
+	case <-exited:
+		return nil, rt.exitError()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case res := <-inv.done:
+		return res, nil
+	case <-timer.C:
+		err := fmt.Errorf("RequestId: %s Task timed out after %d seconds", inv.id, aws.ToInt32(rt.fn.Timeout))
+		rt.mu.Lock()
+		delete(rt.running, inv.id)
+		rt.mu.Unlock()
+		if rerr := rt.restart(cmd, exited); rerr != nil {
+			return nil, fmt.Errorf("%w: %w", err, rerr)
+		}
+		return nil, err
+	case <-exited:
+		return nil, rt.exitError()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (rt *localRuntime) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, runtimeAPIPrefix)
+	log.Printf("[trace] runtime API %s %s", r.Method, r.URL.Path)
+	switch {
+	case r.Method == http.MethodGet && path == "/invocation/next":
+		rt.serveNext(w, r)
+	case r.Method == http.MethodPost && path == "/init/error":
+		b, _ := io.ReadAll(r.Body)
+		log.Printf("[error] init error: %s", string(b))
+		select {
+		case rt.initErr <- b:
+		default:
+		}
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPost && strings.HasPrefix(path, "/invocation/"):
+		parts := strings.Split(strings.TrimPrefix(path, "/invocation/"), "/")
+		if len(parts) != 2 || (parts[1] != "response" && parts[1] != "error") {
+			http.NotFound(w, r)
+			return
+		}
+		rt.serveResult(w, r, parts[0], parts[1] == "error")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (rt *localRuntime) serveNext(w http.ResponseWriter, r *http.Request) {
+	var inv *localInvocation
+	select {
+	case inv = <-rt.next:
+	case <-r.Context().Done():
+		return
+	}
+	rt.mu.Lock()
+	rt.running[inv.id] = inv
+	rt.mu.Unlock()
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	h.Set("Lambda-Runtime-Aws-Request-Id", inv.id)
+	h.Set("Lambda-Runtime-Deadline-Ms", strconv.FormatInt(inv.deadline.UnixMilli(), 10))
+	h.Set("Lambda-Runtime-Invoked-Function-Arn", rt.functionArn)
+	h.Set("Lambda-Runtime-Trace-Id", "Root=1-"+strconv.FormatInt(time.Now().Unix(), 16)+"-"+strings.ReplaceAll(inv.id, "-", "")[:24]+";Sampled=0")
+	w.WriteHeader(http.StatusOK)
+	w.Write(inv.payload)
+}
+
+func (rt *localRuntime) serveResult(w http.ResponseWriter, r *http.Request, id string, isError bool) {
+	rt.mu.Lock()
+	inv, ok := rt.running[id]
+	delete(rt.running, id)
+	rt.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"errorMessage": "invalid request id " + id,
+			"errorType":    "InvalidRequestID",
+		})
+		return
+	}
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	res := &lambda.InvokeOutput{
+		StatusCode:      200,
+		ExecutedVersion: aws.String(versionLatest),
+		Payload:         b,
+	}
+	if isError {
+		res.FunctionError = aws.String("Unhandled")
+	}
+	inv.done <- res
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// newRequestID generates a random UUID v4 string.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
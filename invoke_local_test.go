@@ -0,0 +1,141 @@
+package lambroll
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// fakeBootstrap emulates a runtime client which echoes the payload or reports an error.
+func fakeBootstrap(t *testing.T, endpoint string, n int) {
+	for i := 0; i < n; i++ {
+		res, err := http.Get(endpoint + runtimeAPIPrefix + "/invocation/next")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		payload, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		id := res.Header.Get("Lambda-Runtime-Aws-Request-Id")
+		if id == "" || res.Header.Get("Lambda-Runtime-Deadline-Ms") == "" {
+			t.Errorf("missing runtime headers %v", res.Header)
+		}
+		path := "/invocation/" + id + "/response"
+		if bytes.Contains(payload, []byte("error")) {
+			path = "/invocation/" + id + "/error"
+			payload = []byte(`{"errorMessage":"oops","errorType":"Error"}`)
+		}
+		res, err = http.Post(endpoint+runtimeAPIPrefix+path, "application/json", bytes.NewReader(payload))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusAccepted {
+			t.Errorf("unexpected status %d", res.StatusCode)
+		}
+	}
+}
+
+func TestLocalRuntime(t *testing.T) {
+	rt := &localRuntime{
+		fn:      &Function{FunctionName: aws.String("test"), Timeout: aws.Int32(3)},
+		next:    make(chan *localInvocation),
+		exited:  make(chan struct{}),
+		initErr: make(chan []byte, 1),
+		running: make(map[string]*localInvocation),
+	}
+	ts := httptest.NewServer(rt)
+	defer ts.Close()
+	go fakeBootstrap(t, ts.URL, 2)
+
+	ctx := context.Background()
+	res, err := rt.Invoke(ctx, []byte(`{"foo":"bar"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(res.Payload) != `{"foo":"bar"}` || res.FunctionError != nil {
+		t.Errorf("unexpected response %s %v", string(res.Payload), res.FunctionError)
+	}
+
+	res, err = rt.Invoke(ctx, []byte(`{"error":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.ToString(res.FunctionError) != "Unhandled" {
+		t.Errorf("unexpected function error %v", res.FunctionError)
+	}
+}
+
+func TestLocalRuntimeRestart(t *testing.T) {
+	dir := t.TempDir()
+	started := filepath.Join(dir, "started")
+	bootstrap := filepath.Join(dir, "bootstrap")
+	script := "#!/bin/sh\necho started >> " + started + "\nexec sleep 30\n"
+	if err := os.WriteFile(bootstrap, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	rt := &localRuntime{
+		fn:        &Function{FunctionName: aws.String("test"), Timeout: aws.Int32(1)},
+		bootstrap: bootstrap,
+		dir:       dir,
+		next:      make(chan *localInvocation),
+		initErr:   make(chan []byte, 1),
+		running:   make(map[string]*localInvocation),
+	}
+	ts := httptest.NewServer(rt)
+	rt.server = ts.Config
+	defer ts.Close()
+	if err := rt.start(); err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	// the bootstrap polls the next invocation but never responds
+	go func() {
+		res, err := http.Get(ts.URL + runtimeAPIPrefix + "/invocation/next")
+		if err == nil {
+			res.Body.Close()
+		}
+	}()
+	first, _ := rt.process()
+	if _, err := rt.Invoke(context.Background(), []byte(`{}`)); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error: %v", err)
+	}
+	cmd, exited := rt.process()
+	if cmd == first {
+		t.Fatal("bootstrap is not restarted")
+	}
+	select {
+	case <-exited:
+		t.Fatal("restarted bootstrap exited")
+	default:
+	}
+	time.Sleep(100 * time.Millisecond)
+	b, err := os.ReadFile(started)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(b), "started"); n != 2 {
+		t.Errorf("bootstrap started %d times", n)
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	id := newRequestID()
+	if len(id) != 36 || id[14] != '4' {
+		t.Errorf("unexpected request id %s", id)
+	}
+	if id == newRequestID() {
+		t.Error("request id must be random")
+	}
+}
@@ -0,0 +1,80 @@
+package lambroll
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// invokeStreamFunc returns an invokeFunc by InvokeWithResponseStream API.
+// Payload chunks are written to w as they arrive.
+// The returned InvokeOutput has the whole payload to check the expectations.
+func (app *App) invokeStreamFunc(fn *Function, opt *InvokeOption, w io.Writer) invokeFunc {
+	var logType types.LogType
+	if opt.LogTail {
+		logType = types.LogTypeTail
+	}
+	return func(ctx context.Context, payload []byte) (*lambda.InvokeOutput, error) {
+		in := &lambda.InvokeWithResponseStreamInput{
+			FunctionName:   fn.FunctionName,
+			InvocationType: types.ResponseStreamingInvocationTypeRequestResponse,
+			LogType:        logType,
+			Payload:        payload,
+			Qualifier:      opt.Qualifier,
+		}
+		log.Println("[debug] invoking function with response stream", in)
+		res, err := app.lambda.InvokeWithResponseStream(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("[debug] ResponseStreamContentType:%s", aws.ToString(res.ResponseStreamContentType))
+		stream := res.GetStream()
+		defer stream.Close()
+
+		body, complete := readResponseStream(stream.Events(), w)
+		if err := stream.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read response stream: %w", err)
+		}
+		out := &lambda.InvokeOutput{
+			StatusCode:      res.StatusCode,
+			ExecutedVersion: res.ExecutedVersion,
+			Payload:         body,
+		}
+		if complete == nil {
+			return out, fmt.Errorf("response stream was closed without InvokeComplete event")
+		}
+		if complete.ErrorCode != nil {
+			out.FunctionError = complete.ErrorCode
+			log.Printf("[warn] InvokeComplete ErrorCode:%s ErrorDetails:%s", aws.ToString(complete.ErrorCode), aws.ToString(complete.ErrorDetails))
+		}
+		out.LogResult = complete.LogResult
+		return out, nil
+	}
+}
+
+// readResponseStream writes payload chunks in events to w until the InvokeComplete event.
+// It returns the whole payload and the InvokeComplete event (nil if the stream was closed before it).
+func readResponseStream(events <-chan types.InvokeWithResponseStreamResponseEvent, w io.Writer) ([]byte, *types.InvokeWithResponseStreamCompleteEvent) {
+	var buf bytes.Buffer
+	for ev := range events {
+		switch v := ev.(type) {
+		case *types.InvokeWithResponseStreamResponseEventMemberPayloadChunk:
+			buf.Write(v.Value.Payload)
+			w.Write(v.Value.Payload)
+			if f, ok := w.(interface{ Flush() error }); ok {
+				f.Flush()
+			}
+		case *types.InvokeWithResponseStreamResponseEventMemberInvokeComplete:
+			return buf.Bytes(), &v.Value
+		default:
+			log.Printf("[debug] unknown response stream event %T", v)
+		}
+	}
+	return buf.Bytes(), nil
+}
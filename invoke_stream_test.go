@@ -0,0 +1,58 @@
+package lambroll
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+func TestReadResponseStream(t *testing.T) {
+	events := make(chan types.InvokeWithResponseStreamResponseEvent, 4)
+	events <- &types.InvokeWithResponseStreamResponseEventMemberPayloadChunk{
+		Value: types.InvokeResponseStreamUpdate{Payload: []byte(`{"foo":`)},
+	}
+	events <- &types.InvokeWithResponseStreamResponseEventMemberPayloadChunk{
+		Value: types.InvokeResponseStreamUpdate{Payload: []byte(`"bar"}`)},
+	}
+	events <- &types.InvokeWithResponseStreamResponseEventMemberInvokeComplete{
+		Value: types.InvokeWithResponseStreamCompleteEvent{
+			ErrorCode:    aws.String("Runtime.ExitError"),
+			ErrorDetails: aws.String("exit status 1"),
+		},
+	}
+	close(events)
+
+	w := new(bytes.Buffer)
+	body, complete := readResponseStream(events, w)
+	if w.String() != `{"foo":"bar"}` {
+		t.Errorf("unexpected output %s", w.String())
+	}
+	if string(body) != `{"foo":"bar"}` {
+		t.Errorf("unexpected body %s", string(body))
+	}
+	if complete == nil {
+		t.Fatal("InvokeComplete event is not returned")
+	}
+	if aws.ToString(complete.ErrorCode) != "Runtime.ExitError" || aws.ToString(complete.ErrorDetails) != "exit status 1" {
+		t.Errorf("unexpected complete event %#v", complete)
+	}
+}
+
+func TestReadResponseStreamWithoutComplete(t *testing.T) {
+	events := make(chan types.InvokeWithResponseStreamResponseEvent, 1)
+	events <- &types.InvokeWithResponseStreamResponseEventMemberPayloadChunk{
+		Value: types.InvokeResponseStreamUpdate{Payload: []byte(`partial`)},
+	}
+	close(events)
+
+	w := new(bytes.Buffer)
+	body, complete := readResponseStream(events, w)
+	if string(body) != "partial" || w.String() != "partial" {
+		t.Errorf("unexpected body %s", string(body))
+	}
+	if complete != nil {
+		t.Errorf("unexpected complete event %#v", complete)
+	}
+}
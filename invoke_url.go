@@ -0,0 +1,202 @@
+package lambroll
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// InvokeURLOption represents options for invoking the function via the function URL
+type InvokeURLOption struct {
+	ViaURL bool              `name:"via-url" default:"false" help:"invoke the function via the function URL by HTTP request"`
+	Method string            `default:"GET" help:"HTTP method for --via-url"`
+	Path   string            `default:"/" help:"HTTP path with query string for --via-url"`
+	Header map[string]string `help:"HTTP headers for --via-url"`
+	Body   string            `default:"" help:"HTTP request body for --via-url"`
+}
+
+// functionURLInvoker sends HTTP requests to the function URL.
+type functionURLInvoker struct {
+	url      string
+	authType types.FunctionUrlAuthType
+	opt      InvokeURLOption
+	client   *http.Client
+	awsCfg   aws.Config
+	signer   *v4.Signer
+}
+
+func (app *App) newFunctionURLInvoker(ctx context.Context, fn *Function, qualifier *string, opt InvokeURLOption) (*functionURLInvoker, error) {
+	res, err := app.lambda.GetFunctionUrlConfig(ctx, &lambda.GetFunctionUrlConfigInput{
+		FunctionName: fn.FunctionName,
+		Qualifier:    qualifier,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get function url config of %s: %w", fullQualifiedFunctionName(*fn.FunctionName, qualifier), err)
+	}
+	log.Printf("[debug] function url %s AuthType:%s InvokeMode:%s", aws.ToString(res.FunctionUrl), res.AuthType, res.InvokeMode)
+	return &functionURLInvoker{
+		url:      aws.ToString(res.FunctionUrl),
+		authType: res.AuthType,
+		opt:      opt,
+		client:   &http.Client{},
+		awsCfg:   app.awsConfig,
+		signer:   v4.NewSigner(),
+	}, nil
+}
+
+func (u *functionURLInvoker) newRequest(ctx context.Context, body []byte) (*http.Request, error) {
+	endpoint := strings.TrimSuffix(u.url, "/") + "/" + strings.TrimPrefix(u.opt.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, u.opt.Method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range u.opt.Header {
+		req.Header.Set(k, v)
+	}
+	if u.authType == types.FunctionUrlAuthTypeAwsIam {
+		if err := u.sign(ctx, req, body); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// sign signs the request by SigV4 with the credentials of the current session.
+func (u *functionURLInvoker) sign(ctx context.Context, req *http.Request, body []byte) error {
+	creds, err := u.awsCfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve credentials: %w", err)
+	}
+	h := sha256.Sum256(body)
+	if err := u.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(h[:]), "lambda", u.awsCfg.Region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+	return nil
+}
+
+// Invoke sends a request and returns the response as an InvokeOutput.
+func (u *functionURLInvoker) Invoke(ctx context.Context, body []byte) (*lambda.InvokeOutput, error) {
+	req, err := u.newRequest(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[debug] %s %s", req.Method, req.URL)
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request %s: %w", req.URL, err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return newInvokeOutputFromHTTP(resp, b), nil
+}
+
+// Stream sends a request and writes the response body to w as it arrives.
+// It supports the function URL with InvokeMode=RESPONSE_STREAM.
+func (u *functionURLInvoker) Stream(ctx context.Context, body []byte, w io.Writer) (*lambda.InvokeOutput, error) {
+	req, err := u.newRequest(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[debug] %s %s", req.Method, req.URL)
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request %s: %w", req.URL, err)
+	}
+	defer resp.Body.Close()
+	for k, vs := range resp.Header {
+		for _, v := range vs {
+			log.Printf("[debug] %s: %s", k, v)
+		}
+	}
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			w.Write(buf[:n])
+			if f, ok := w.(interface{ Flush() error }); ok {
+				f.Flush()
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+	}
+	return newInvokeOutputFromHTTP(resp, nil), nil
+}
+
+func newInvokeOutputFromHTTP(resp *http.Response, body []byte) *lambda.InvokeOutput {
+	res := &lambda.InvokeOutput{
+		StatusCode: int32(resp.StatusCode),
+		Payload:    body,
+	}
+	if v := resp.Header.Get("X-Amz-Function-Error"); v != "" {
+		res.FunctionError = aws.String(v)
+	}
+	if v := resp.Header.Get("X-Amz-Executed-Version"); v != "" {
+		res.ExecutedVersion = aws.String(v)
+	}
+	return res
+}
+
+// invokeViaURL invokes the function via the function URL.
+func (app *App) invokeViaURL(ctx context.Context, fn *Function, opt *InvokeOption, expectations InvokeExpectations) error {
+	u, err := app.newFunctionURLInvoker(ctx, fn, opt.Qualifier, opt.InvokeURLOption)
+	if err != nil {
+		return err
+	}
+	body := []byte(opt.Body)
+	if opt.InvokeLoadOption.enabled() {
+		summary, err := invokeLoad(ctx, u.Invoke, [][]byte{body}, opt.InvokeLoadOption)
+		if summary != nil {
+			fmt.Print(summary.String())
+		}
+		return err
+	}
+
+	var res *lambda.InvokeOutput
+	if e := expectations.For(0); e != nil {
+		// buffer the response to check
+		if res, err = u.Invoke(ctx, body); err != nil {
+			return err
+		}
+		os.Stdout.Write(res.Payload)
+		os.Stdout.Write([]byte("\n"))
+	} else {
+		if res, err = u.Stream(ctx, body, os.Stdout); err != nil {
+			return err
+		}
+		os.Stdout.Write([]byte("\n"))
+	}
+	log.Printf("[info] StatusCode:%d", res.StatusCode)
+	if res.FunctionError != nil {
+		log.Printf("[warn] FunctionError:%s", *res.FunctionError)
+	}
+	if e := expectations.For(0); e != nil {
+		if failures := e.Check(res); len(failures) > 0 {
+			for _, f := range failures {
+				log.Printf("[error] %s", f)
+			}
+			return fmt.Errorf("the response did not satisfy the expectations")
+		}
+		log.Println("[info] expectations satisfied")
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package lambroll
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+func TestFunctionURLInvoker(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIAEXAMPLE/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if !strings.Contains(r.Header.Get("Authorization"), "/us-east-1/lambda/aws4_request") {
+			t.Errorf("unexpected scope %s", r.Header.Get("Authorization"))
+		}
+		if r.URL.Path != "/foo" || r.URL.Query().Get("bar") != "baz" || r.Header.Get("X-Foo") != "foo" {
+			t.Errorf("unexpected request %s %v", r.URL, r.Header)
+		}
+		b, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		for _, s := range []string{"hello ", string(b)} {
+			w.Write([]byte(s))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer ts.Close()
+
+	u := &functionURLInvoker{
+		url:      ts.URL + "/",
+		authType: types.FunctionUrlAuthTypeAwsIam,
+		opt: InvokeURLOption{
+			Method: "POST",
+			Path:   "/foo?bar=baz",
+			Header: map[string]string{"X-Foo": "foo"},
+		},
+		client: ts.Client(),
+		awsCfg: aws.Config{
+			Region: "us-east-1",
+			Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+				return aws.Credentials{AccessKeyID: "AKIAEXAMPLE", SecretAccessKey: "secret"}, nil
+			}),
+		},
+		signer: v4.NewSigner(),
+	}
+	ctx := context.Background()
+	res, err := u.Invoke(ctx, []byte("world"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusCreated || string(res.Payload) != "hello world" {
+		t.Errorf("unexpected response %d %s", res.StatusCode, string(res.Payload))
+	}
+
+	var buf bytes.Buffer
+	res, err = u.Stream(ctx, []byte("stream"), &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusCreated || buf.String() != "hello stream" {
+		t.Errorf("unexpected response %d %s", res.StatusCode, buf.String())
+	}
+
+	u.authType = types.FunctionUrlAuthTypeNone
+	res, err = u.Invoke(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("unsigned request must be forbidden, got %d", res.StatusCode)
+	}
+}
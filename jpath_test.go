@@ -0,0 +1,48 @@
+package lambroll
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestJsonnetImportPaths(t *testing.T) {
+	// vendor is resolved relative to the definition file, not to the current directory
+	for _, s := range []struct {
+		jpath   []string
+		tracing string
+	}{
+		{jpath: []string{"test/jpath/lib"}, tracing: "Active"},
+		{jpath: []string{"test/jpath/lib", "test/jpath/lib2"}, tracing: "Active"},
+		{jpath: []string{"test/jpath/lib2", "test/jpath/lib"}, tracing: "PassThrough"},
+		{jpath: []string{"test/jpath/lib2" + string(os.PathListSeparator) + "test/jpath/lib"}, tracing: "PassThrough"},
+	} {
+		app, err := New(context.Background(), &Option{JPath: s.jpath})
+		if err != nil {
+			t.Fatal(err)
+		}
+		fn, err := app.loadFunction("test/jpath/function.jsonnet")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := string(fn.TracingConfig.Mode); mode != s.tracing {
+			t.Errorf("jpath %v: unexpected TracingConfig.Mode %s", s.jpath, mode)
+		}
+		if f := string(fn.LoggingConfig.LogFormat); f != "JSON" {
+			t.Errorf("unexpected LoggingConfig.LogFormat %s", f)
+		}
+		if name := aws.ToString(fn.FunctionName); name != "hello" {
+			t.Errorf("unexpected FunctionName %s", name)
+		}
+	}
+
+	app, err := New(context.Background(), &Option{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.loadFunction("test/jpath/function.jsonnet"); err == nil {
+		t.Error("expected error without jpath")
+	}
+}
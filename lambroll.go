@@ -11,9 +11,11 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	awsssm "github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/fujiwara/ssm-lookup/ssm"
 	"github.com/fujiwara/tfstate-lookup/tfstate"
@@ -69,6 +71,9 @@ var (
 	// FunctionZipFilename defines file name for zip archive downloaded at init.
 	FunctionZipFilename = "function.zip"
 
+	// JsonnetVendorDir defines the directory of vendored Jsonnet libraries to import.
+	JsonnetVendorDir = "vendor"
+
 	// DefaultExcludes is a preset excludes file list
 	DefaultExcludes = []string{
 		IgnoreFilename,
@@ -89,6 +94,7 @@ var (
 // App represents lambroll application
 type App struct {
 	accountID string
+	callerArn string
 	profile   string
 	loader    *config.Loader
 
@@ -97,6 +103,22 @@ type App struct {
 
 	extStr  map[string]string
 	extCode map[string]string
+	strict  bool
+
+	// env is the name of the environment to merge the overlay definition files
+	env string
+	// layers represents origins of the values by the path of definition files merged with the overlay
+	layers map[string]map[string]string
+
+	// secrets looks up values from Parameter Store and Secrets Manager for template functions
+	secrets *secretLookup
+	// jpaths are the Jsonnet import search paths specified by --jpath. Later entries take precedence.
+	jpaths []string
+	// nativeFuncs are the lookup functions for Jsonnet
+	nativeFuncs []*jsonnet.NativeFunction
+
+	// tfstates represents URLs of tfstate by the prefix of template function name
+	tfstates map[string]string
 
 	functionFilePath string
 }
@@ -111,7 +133,7 @@ func newAwsConfig(ctx context.Context, opt *Option) (aws.Config, error) {
 	}
 	if opt.Endpoint != nil && *opt.Endpoint != "" {
 		customResolver := aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
-			if service == lambda.ServiceID || service == sts.ServiceID || service == s3.ServiceID {
+			if service == lambda.ServiceID || service == sts.ServiceID || service == s3.ServiceID || service == cloudwatchlogs.ServiceID {
 				return aws.Endpoint{
 					PartitionID:   "aws",
 					URL:           *opt.Endpoint,
@@ -149,20 +171,19 @@ func New(ctx context.Context, opt *Option) (*App, error) {
 
 	loader := config.New()
 
-	// load ssm functions
-	if ssmFuncs, err := ssm.FuncMap(ctx, v2cfg); err != nil {
-		return nil, err
-	} else {
-		loader.Funcs(ssmFuncs)
-	}
+	// load ssm, ssm_by_path and secretsmanager functions sharing the cache of parameters
+	secrets := newSecretLookup(awsssm.NewFromConfig(v2cfg))
+	loader.Funcs(secrets.FuncMap(ctx, ssm.New(v2cfg, secrets.cache)))
 
 	// load tfstate functions
+	tfstates := make(map[string]string)
 	if opt.TFState != nil && *opt.TFState != "" {
 		funcs, err := tfstate.FuncMap(ctx, *opt.TFState)
 		if err != nil {
 			return nil, err
 		}
 		loader.Funcs(funcs)
+		tfstates[""] = *opt.TFState
 	}
 	if len(opt.PrefixedTFState) > 0 {
 		prefixedFuncs := make(template.FuncMap)
@@ -177,6 +198,7 @@ func New(ctx context.Context, opt *Option) (*App, error) {
 			for name, f := range funcs {
 				prefixedFuncs[prefix+name] = f
 			}
+			tfstates[prefix] = path
 		}
 		loader.Funcs(prefixedFuncs)
 	}
@@ -190,6 +212,13 @@ func New(ctx context.Context, opt *Option) (*App, error) {
 	}
 	app.extStr = opt.ExtStr
 	app.extCode = opt.ExtCode
+	app.strict = opt.Strict
+	app.env = opt.Env
+	app.layers = make(map[string]map[string]string)
+	app.tfstates = tfstates
+	app.secrets = secrets
+	app.nativeFuncs = app.jsonnetNativeFunctions(ctx)
+	app.jpaths = jsonnetImportPaths(opt.JPath)
 
 	return app, nil
 }
@@ -206,14 +235,60 @@ func (app *App) AWSAccountID(ctx context.Context) string {
 		return ""
 	}
 	app.accountID = *r.Account
+	app.callerArn = aws.ToString(r.Arn)
 	return app.accountID
 }
 
+// CallerArn returns ARN of the caller identity in current session
+func (app *App) CallerArn(ctx context.Context) string {
+	if app.callerArn == "" {
+		app.AWSAccountID(ctx)
+	}
+	return app.callerArn
+}
+
 func loadDefinitionFile[T any](app *App, path string, defaults []string) (*T, error) {
+	path, src, err := app.renderDefinitionFile(path, defaults)
+	if err != nil {
+		return nil, err
+	}
+	return decodeDefinition[T](app, src, path)
+}
+
+// loadOverlaidDefinitionFile loads the definition file merged with the overlay for --env.
+// Only function and function URL definitions are overlaid.
+func loadOverlaidDefinitionFile[T any](app *App, path string, defaults []string) (*T, error) {
+	files, src, err := app.renderOverlaidDefinitionFile(path, defaults)
+	if err != nil {
+		return nil, err
+	}
+	return decodeDefinition[T](app, src, files...)
+}
+
+// decodeDefinition decodes the rendered definition src of the files.
+// files are the definition file and its overlay if merged.
+func decodeDefinition[T any](app *App, src []byte, files ...string) (*T, error) {
+	path := files[0]
+	var v T
+	if app.strict {
+		if err := strictUnmarshalJSON(src, &v, files...); err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		return &v, nil
+	}
+	if err := unmarshalJSON(src, &v, path); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	return &v, nil
+}
+
+// renderDefinitionFile renders the definition file (JSON or Jsonnet) with the template functions.
+// It returns the path of the definition file and the rendered JSON.
+func (app *App) renderDefinitionFile(path string, defaults []string) (string, []byte, error) {
 	if path == "" {
 		p, err := findDefinitionFile("", defaults)
 		if err != nil {
-			return nil, err
+			return "", nil, err
 		}
 		path = p
 	}
@@ -224,36 +299,90 @@ func loadDefinitionFile[T any](app *App, path string, defaults []string) (*T, er
 	)
 	switch filepath.Ext(path) {
 	case ".jsonnet":
-		vm := jsonnet.MakeVM()
-		for k, v := range app.extStr {
-			vm.ExtVar(k, v)
-		}
-		for k, v := range app.extCode {
-			vm.ExtCode(k, v)
-		}
-		jsonStr, err := vm.EvaluateFile(path)
+		jsonStr, err := app.jsonnetVM(path).EvaluateFile(path)
 		if err != nil {
-			return nil, err
+			return "", nil, err
 		}
 		src, err = app.loader.ReadWithEnvBytes([]byte(jsonStr))
 		if err != nil {
-			return nil, err
+			return "", nil, err
 		}
 	default:
 		src, err = app.loader.ReadWithEnv(path)
 		if err != nil {
-			return nil, err
+			return "", nil, err
 		}
 	}
-	var v T
-	if err := unmarshalJSON(src, &v, path); err != nil {
-		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	return path, src, nil
+}
+
+// renderOverlaidDefinitionFile renders the definition file and merges the overlay file for --env.
+// It returns the paths of the definition file and the overlay file (if exists) and the rendered JSON.
+func (app *App) renderOverlaidDefinitionFile(path string, defaults []string) ([]string, []byte, error) {
+	path, src, err := app.renderDefinitionFile(path, defaults)
+	if err != nil {
+		return nil, nil, err
 	}
-	return &v, nil
+	overlayPath := overlayFilePath(path, app.env)
+	if overlayPath == "" {
+		return []string{path}, src, nil
+	}
+	merged, origins, err := app.applyOverlay(path, src, overlayPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to merge %s: %w", overlayPath, err)
+	}
+	if app.layers != nil {
+		app.layers[path] = origins
+	}
+	return []string{path, overlayPath}, merged, nil
+}
+
+// jsonnetVM returns a Jsonnet VM with the external variables and the native functions
+// to evaluate the definition file at path.
+func (app *App) jsonnetVM(path string) *jsonnet.VM {
+	vm := jsonnet.MakeVM()
+	for k, v := range app.extStr {
+		vm.ExtVar(k, v)
+	}
+	for k, v := range app.extCode {
+		vm.ExtCode(k, v)
+	}
+	for _, f := range app.nativeFuncs {
+		vm.NativeFunction(f)
+	}
+	vm.Importer(app.jsonnetImporter(path))
+	return vm
+}
+
+// jsonnetImporter returns the importer for the definition file at path.
+// The vendor directory (e.g. installed by jsonnet-bundler) next to the definition file is searched at last.
+func (app *App) jsonnetImporter(path string) *jsonnet.FileImporter {
+	paths := make([]string, 0, len(app.jpaths)+1)
+	vendor := filepath.Join(filepath.Dir(path), JsonnetVendorDir)
+	if st, err := os.Stat(vendor); err == nil && st.IsDir() {
+		paths = append(paths, vendor)
+	}
+	return &jsonnet.FileImporter{JPaths: append(paths, app.jpaths...)}
+}
+
+// jsonnetImportPaths returns the import search paths for jsonnet.FileImporter.
+// Each of jpaths may be a list of paths separated by os.PathListSeparator like JSONNET_PATH.
+// The former paths take precedence.
+func jsonnetImportPaths(jpaths []string) []string {
+	var list []string
+	for _, p := range jpaths {
+		list = append(list, filepath.SplitList(p)...)
+	}
+	// FileImporter searches JPaths from the last entry
+	paths := make([]string, 0, len(list))
+	for i := len(list) - 1; i >= 0; i-- {
+		paths = append(paths, list[i])
+	}
+	return paths
 }
 
 func (app *App) loadFunction(path string) (*Function, error) {
-	return loadDefinitionFile[Function](app, path, DefaultFunctionFilenames)
+	return loadOverlaidDefinitionFile[Function](app, path, DefaultFunctionFilenames)
 }
 
 func newFunctionFrom(c *types.FunctionConfiguration, code *types.FunctionCodeLocation, tags Tags) *Function {
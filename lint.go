@@ -0,0 +1,297 @@
+package lambroll
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-jsonnet"
+	"github.com/itchyny/gojq"
+)
+
+// LintOption represents options for Lint()
+type LintOption struct {
+	Rules       []string `help:"paths or URLs of lint rule files" required:"" env:"LAMBROLL_LINT_RULES"`
+	FunctionURL string   `help:"path to function-url definiton" default:"" env:"LAMBROLL_FUNCTION_URL"`
+}
+
+const (
+	lintSeverityError   = "error"
+	lintSeverityWarning = "warning"
+)
+
+// lintRulesFetchTimeout is the timeout to fetch lint rules from http(s) URLs.
+var lintRulesFetchTimeout = 30 * time.Second
+
+// LintRules represents a lint rule file.
+type LintRules struct {
+	Rules []*LintRule `json:"Rules"`
+}
+
+// LintRule represents a rule for the function definition.
+// The rule is evaluated for {"Function": ..., "FunctionURL": ...}.
+type LintRule struct {
+	// Name is the name of the rule.
+	Name string `json:"Name"`
+	// Description is shown when the rule is violated.
+	Description string `json:"Description,omitempty"`
+	// Severity is "error" (default) or "warning". Only errors fail the lint.
+	Severity string `json:"Severity,omitempty"`
+	// When is a jq expression. The rule is applied only when it returns true.
+	When string `json:"When,omitempty"`
+	// Query is a jq expression which must return true.
+	Query string `json:"Query,omitempty"`
+	// Jsonnet is a Jsonnet expression which must be evaluated to true. Function and FunctionURL are available as local variables.
+	Jsonnet string `json:"Jsonnet,omitempty"`
+
+	when  *gojq.Code
+	query *gojq.Code
+}
+
+func (r *LintRule) compile() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule Name is required")
+	}
+	switch r.Severity {
+	case "":
+		r.Severity = lintSeverityError
+	case lintSeverityError, lintSeverityWarning:
+	default:
+		return fmt.Errorf("rule %s: unknown Severity %s", r.Name, r.Severity)
+	}
+	if (r.Query == "") == (r.Jsonnet == "") {
+		return fmt.Errorf("rule %s: either Query or Jsonnet is required", r.Name)
+	}
+	var err error
+	if r.When != "" {
+		if r.when, err = compileJQ(r.When); err != nil {
+			return fmt.Errorf("rule %s: failed to compile When: %w", r.Name, err)
+		}
+	}
+	if r.Query != "" {
+		if r.query, err = compileJQ(r.Query); err != nil {
+			return fmt.Errorf("rule %s: failed to compile Query: %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+func compileJQ(s string) (*gojq.Code, error) {
+	q, err := gojq.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return gojq.Compile(q)
+}
+
+// runJQ runs the query and reports whether the first result is true.
+func runJQ(code *gojq.Code, input any) (bool, error) {
+	v, ok := code.Run(input).Next()
+	if !ok {
+		return false, nil
+	}
+	if err, isErr := v.(error); isErr {
+		return false, err
+	}
+	return v == true, nil
+}
+
+// lintViolation represents a violation of the rule.
+type lintViolation struct {
+	Rule    *LintRule
+	Message string
+}
+
+func (v *lintViolation) String() string {
+	s := v.Rule.Name
+	if v.Rule.Description != "" {
+		s += ": " + v.Rule.Description
+	}
+	if v.Message != "" {
+		s += " (" + v.Message + ")"
+	}
+	return s
+}
+
+// linter evaluates lint rules.
+type linter struct {
+	rules   []*LintRule
+	extStr  map[string]string
+	extCode map[string]string
+}
+
+// newLintInput returns the input document of the rules.
+func newLintInput(fn *Function, fu *FunctionURL) (map[string]any, error) {
+	fnAny, err := marshalAny(fn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal function: %w", err)
+	}
+	input := map[string]any{"Function": fnAny, "FunctionURL": nil}
+	if fu != nil {
+		if input["FunctionURL"], err = marshalAny(fu); err != nil {
+			return nil, fmt.Errorf("failed to marshal function url: %w", err)
+		}
+	}
+	return input, nil
+}
+
+// Lint evaluates all rules for the input and returns violations.
+func (l *linter) Lint(input map[string]any) []*lintViolation {
+	var violations []*lintViolation
+	for _, r := range l.rules {
+		if r.when != nil {
+			ok, err := runJQ(r.when, input)
+			if err != nil {
+				violations = append(violations, &lintViolation{Rule: r, Message: "When: " + err.Error()})
+				continue
+			}
+			if !ok {
+				log.Printf("[debug] rule %s is skipped", r.Name)
+				continue
+			}
+		}
+		var ok bool
+		var err error
+		if r.query != nil {
+			ok, err = runJQ(r.query, input)
+		} else {
+			ok, err = l.evaluateJsonnet(r.Jsonnet, input)
+		}
+		switch {
+		case err != nil:
+			violations = append(violations, &lintViolation{Rule: r, Message: err.Error()})
+		case !ok:
+			violations = append(violations, &lintViolation{Rule: r})
+		default:
+			log.Printf("[debug] rule %s passed", r.Name)
+		}
+	}
+	return violations
+}
+
+func (l *linter) evaluateJsonnet(expr string, input map[string]any) (bool, error) {
+	vm := jsonnet.MakeVM()
+	for k, v := range l.extStr {
+		vm.ExtVar(k, v)
+	}
+	for k, v := range l.extCode {
+		vm.ExtCode(k, v)
+	}
+	fn, _ := json.Marshal(input["Function"])
+	fu, _ := json.Marshal(input["FunctionURL"])
+	snippet := fmt.Sprintf("local Function = %s;\nlocal FunctionURL = %s;\n%s", fn, fu, expr)
+	out, err := vm.EvaluateAnonymousSnippet("rule.jsonnet", snippet)
+	if err != nil {
+		return false, fmt.Errorf("%s", strings.TrimPrefix(strings.SplitN(err.Error(), "\n", 2)[0], "RUNTIME ERROR: "))
+	}
+	var v any
+	if err := json.Unmarshal([]byte(out), &v); err != nil {
+		return false, err
+	}
+	return v == true, nil
+}
+
+func (app *App) newLinter(ctx context.Context, paths []string) (*linter, error) {
+	l := &linter{extStr: app.extStr, extCode: app.extCode}
+	for _, p := range paths {
+		rules, err := app.loadLintRules(ctx, p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load lint rules %s: %w", p, err)
+		}
+		for _, r := range rules.Rules {
+			if err := r.compile(); err != nil {
+				return nil, fmt.Errorf("invalid lint rules %s: %w", p, err)
+			}
+		}
+		l.rules = append(l.rules, rules.Rules...)
+	}
+	return l, nil
+}
+
+// loadLintRules loads the rule file from the path or http(s) URL.
+// Remote rules are evaluated as plain Jsonnet (or JSON) without templates, overlays and imports.
+func (app *App) loadLintRules(ctx context.Context, p string) (*LintRules, error) {
+	if !strings.HasPrefix(p, "https://") && !strings.HasPrefix(p, "http://") {
+		return loadDefinitionFile[LintRules](app, p, nil)
+	}
+	src, err := fetchLintRules(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	vm := jsonnet.MakeVM()
+	vm.Importer(&jsonnet.MemoryImporter{})
+	out, err := vm.EvaluateAnonymousSnippet(p, string(src))
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate: %w", err)
+	}
+	var rules LintRules
+	if err := unmarshalJSON([]byte(out), &rules, p); err != nil {
+		return nil, fmt.Errorf("failed to parse: %w", err)
+	}
+	return &rules, nil
+}
+
+func fetchLintRules(ctx context.Context, u string) ([]byte, error) {
+	log.Printf("[debug] fetching lint rules from %s", u)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: lintRulesFetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// lint lints the function (and the function url) with the rules.
+// It returns an error when some error rules are violated.
+func (app *App) lint(ctx context.Context, fn *Function, fu *FunctionURL, rules []string) error {
+	l, err := app.newLinter(ctx, rules)
+	if err != nil {
+		return err
+	}
+	input, err := newLintInput(fn, fu)
+	if err != nil {
+		return err
+	}
+	var errors int
+	for _, v := range l.Lint(input) {
+		if v.Rule.Severity == lintSeverityWarning {
+			log.Printf("[warn] %s", v)
+		} else {
+			log.Printf("[error] %s", v)
+			errors++
+		}
+	}
+	if errors > 0 {
+		return fmt.Errorf("%d lint rules violated", errors)
+	}
+	log.Printf("[info] %d lint rules passed", len(l.rules))
+	return nil
+}
+
+// Lint lints the function definitions by the rules.
+func (app *App) Lint(ctx context.Context, opt *LintOption) error {
+	fn, err := app.loadFunction(app.functionFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to load function: %w", err)
+	}
+	var fu *FunctionURL
+	if opt.FunctionURL != "" {
+		if fu, err = app.loadFunctionUrl(opt.FunctionURL, *fn.FunctionName); err != nil {
+			return fmt.Errorf("failed to load function url config: %w", err)
+		}
+	}
+	return app.lint(ctx, fn, fu, opt.Rules)
+}
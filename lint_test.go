@@ -0,0 +1,163 @@
+package lambroll
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+var lintTestRules = []*LintRule{
+	{
+		Name:  "tracing-active",
+		Query: `.Function.TracingConfig.Mode == "Active"`,
+	},
+	{
+		Name:     "required-tags",
+		Severity: "warning",
+		Query:    `.Function.Tags | has("Owner") and has("Env")`,
+	},
+	{
+		Name:  "public-url-cors",
+		When:  `.FunctionURL.Config.AuthType == "NONE"`,
+		Query: `(.FunctionURL.Config.Cors.AllowOrigins // []) | length > 0 and all(. != "*")`,
+	},
+	{
+		Name:    "memory-size",
+		Jsonnet: `Function.MemorySize >= 256`,
+	},
+	{
+		Name:    "runtime",
+		Jsonnet: `assert Function.Runtime != 'nodejs16.x' : 'nodejs16.x is deprecated'; true`,
+	},
+}
+
+func TestLinter(t *testing.T) {
+	for _, r := range lintTestRules {
+		if err := r.compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l := &linter{rules: lintTestRules}
+
+	fn := &Function{
+		FunctionName:  aws.String("hello"),
+		Runtime:       types.RuntimeNodejs16x,
+		MemorySize:    aws.Int32(128),
+		TracingConfig: &types.TracingConfig{Mode: types.TracingModePassThrough},
+		Tags:          map[string]string{"Owner": "me"},
+	}
+	fu := &FunctionURL{
+		Config: &FunctionURLConfig{
+			AuthType: types.FunctionUrlAuthTypeNone,
+			Cors:     &types.Cors{AllowOrigins: []string{"*"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		modify   func()
+		fu       *FunctionURL
+		expected []string
+	}{
+		{
+			name:     "violated",
+			modify:   func() {},
+			fu:       fu,
+			expected: []string{"memory-size", "public-url-cors", "required-tags", "runtime: nodejs16.x is deprecated", "tracing-active"},
+		},
+		{
+			name:     "without function url",
+			modify:   func() {},
+			expected: []string{"memory-size", "required-tags", "runtime: nodejs16.x is deprecated", "tracing-active"},
+		},
+		{
+			name: "passed",
+			modify: func() {
+				fn.Runtime = types.RuntimeNodejs20x
+				fn.MemorySize = aws.Int32(512)
+				fn.TracingConfig.Mode = types.TracingModeActive
+				fn.Tags["Env"] = "prod"
+				fu.Config.Cors.AllowOrigins = []string{"https://example.com"}
+			},
+			fu:       fu,
+			expected: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.modify()
+			input, err := newLintInput(fn, tt.fu)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, v := range l.Lint(input) {
+				s := v.Rule.Name
+				if v.Message != "" {
+					s += ": " + v.Message
+				}
+				got = append(got, s)
+			}
+			sort.Strings(got)
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestLintRuleCompile(t *testing.T) {
+	invalids := []*LintRule{
+		{Query: "true"},
+		{Name: "no-query"},
+		{Name: "both", Query: "true", Jsonnet: "true"},
+		{Name: "severity", Query: "true", Severity: "fatal"},
+		{Name: "syntax", Query: ".Function |"},
+	}
+	for _, r := range invalids {
+		if err := r.compile(); err == nil {
+			t.Errorf("expected error for %#v", r)
+		}
+	}
+}
+
+func TestLoadRemoteLintRules(t *testing.T) {
+	files := map[string]string{
+		// templates are not rendered for remote rules
+		"/rules.json":     `{"Rules":[{"Name":"template","Query":"(.Function.Description // \"\") | contains(\"{{ must_env ` + "`FOO`" + ` }}\") | not"}]}`,
+		"/rules.jsonnet":  `{ Rules: [{ Name: 'runtime', Jsonnet: "Function.Runtime != 'nodejs16.x'" }] }`,
+		"/import.jsonnet": `import 'rules.libsonnet'`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		src, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(src))
+	}))
+	defer ts.Close()
+
+	app := &App{}
+	ctx := context.Background()
+	for _, name := range []string{"/rules.json", "/rules.jsonnet"} {
+		rules, err := app.loadLintRules(ctx, ts.URL+name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rules.Rules) != 1 || rules.Rules[0].compile() != nil {
+			t.Errorf("unexpected rules %#v", rules.Rules)
+		}
+	}
+	for _, name := range []string{"/import.jsonnet", "/notfound.json"} {
+		if _, err := app.loadLintRules(ctx, ts.URL+name); err == nil {
+			t.Errorf("expected error for %s", name)
+		}
+	}
+}
@@ -2,43 +2,269 @@ package lambroll
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/fujiwara/lambroll/wildcard"
+	"github.com/olekukonko/tablewriter"
 )
 
 // ListOption represents options for List()
 type ListOption struct {
+	Name        string   `default:"" help:"filter by function name glob pattern (e.g. 'app-*')"`
+	Runtime     []string `help:"filter by runtimes"`
+	Tag         []string `help:"filter by tags. KEY=VALUE matches the value, KEY matches functions which have the tag"`
+	PackageType string   `default:"" enum:",Zip,Image" help:"filter by package type (Zip or Image)"`
+	Output      string   `default:"json" enum:"json,jsonl,table,tsv" help:"output format. json prints the function definitions"`
+	Columns     []string `default:"FunctionName,Runtime,PackageType,MemorySize,Timeout,LastModified" help:"columns for jsonl, table and tsv output. Tags.KEY shows the value of the tag"`
+	Tags        bool     `default:"false" help:"add the Tags column to jsonl, table and tsv output"`
+	Concurrency int      `default:"8" help:"number of concurrent requests to fetch tags"`
+}
+
+// listedFunction represents a function and its tags in the list.
+type listedFunction struct {
+	config types.FunctionConfiguration
+	tags   Tags
+}
+
+// listColumns represents functions to get the column values from the function.
+var listColumns = map[string]func(f *listedFunction) any{
+	"FunctionName":  func(f *listedFunction) any { return aws.ToString(f.config.FunctionName) },
+	"FunctionArn":   func(f *listedFunction) any { return aws.ToString(f.config.FunctionArn) },
+	"Runtime":       func(f *listedFunction) any { return string(f.config.Runtime) },
+	"Handler":       func(f *listedFunction) any { return aws.ToString(f.config.Handler) },
+	"PackageType":   func(f *listedFunction) any { return string(f.config.PackageType) },
+	"Architectures": func(f *listedFunction) any { return f.config.Architectures },
+	"MemorySize":    func(f *listedFunction) any { return aws.ToInt32(f.config.MemorySize) },
+	"Timeout":       func(f *listedFunction) any { return aws.ToInt32(f.config.Timeout) },
+	"CodeSize":      func(f *listedFunction) any { return f.config.CodeSize },
+	"LastModified":  func(f *listedFunction) any { return aws.ToString(f.config.LastModified) },
+	"Description":   func(f *listedFunction) any { return aws.ToString(f.config.Description) },
+	"Role":          func(f *listedFunction) any { return aws.ToString(f.config.Role) },
+	"Version":       func(f *listedFunction) any { return aws.ToString(f.config.Version) },
+	"Tags":          func(f *listedFunction) any { return f.tags },
+}
+
+func listColumnValue(f *listedFunction, column string) any {
+	if key, ok := strings.CutPrefix(column, "Tags."); ok {
+		return f.tags[key]
+	}
+	return listColumns[column](f)
+}
+
+func listColumnString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int32:
+		return strconv.Itoa(int(v))
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case []types.Architecture:
+		ss := make([]string, 0, len(v))
+		for _, a := range v {
+			ss = append(ss, string(a))
+		}
+		return strings.Join(ss, ",")
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+func (opt *ListOption) validate() error {
+	for _, c := range opt.Columns {
+		if _, ok := listColumns[c]; !ok && !strings.HasPrefix(c, "Tags.") {
+			return fmt.Errorf("unknown column %s", c)
+		}
+	}
+	return nil
+}
+
+// columns returns the columns for jsonl, table and tsv output.
+// --tags adds the Tags column unless it is specified already.
+func (opt *ListOption) columns() []string {
+	if !opt.Tags || isOneOf("Tags", opt.Columns) {
+		return opt.Columns
+	}
+	return append(append([]string{}, opt.Columns...), "Tags")
+}
+
+// needTags reports whether tags of functions are required.
+// json output always includes tags as same as function.json.
+func (opt *ListOption) needTags() bool {
+	if opt.Output == "json" || len(opt.Tag) > 0 {
+		return true
+	}
+	for _, c := range opt.columns() {
+		if c == "Tags" || strings.HasPrefix(c, "Tags.") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchConfig reports whether the function configuration matches the filters except tags.
+func (opt *ListOption) matchConfig(c *types.FunctionConfiguration) bool {
+	if opt.Name != "" && !wildcard.Match(opt.Name, aws.ToString(c.FunctionName)) {
+		return false
+	}
+	if len(opt.Runtime) > 0 && !isOneOf(string(c.Runtime), opt.Runtime) {
+		return false
+	}
+	if opt.PackageType != "" && string(c.PackageType) != opt.PackageType {
+		return false
+	}
+	return true
+}
+
+// matchTags reports whether the tags match all the tag filters.
+func (opt *ListOption) matchTags(tags Tags) bool {
+	for _, t := range opt.Tag {
+		key, value, hasValue := strings.Cut(t, "=")
+		v, ok := tags[key]
+		if !ok || hasValue && v != value {
+			return false
+		}
+	}
+	return true
 }
 
 // List lists lambda functions
 func (app *App) List(ctx context.Context, opt *ListOption) error {
+	if err := opt.validate(); err != nil {
+		return err
+	}
+	functions, err := app.listFunctions(ctx, opt)
+	if err != nil {
+		return err
+	}
+	return writeListedFunctions(os.Stdout, functions, opt)
+}
+
+// listFunctions lists all functions which match the filters.
+func (app *App) listFunctions(ctx context.Context, opt *ListOption) ([]*listedFunction, error) {
+	var functions []*listedFunction
 	var marker *string
 	for {
 		res, err := app.lambda.ListFunctions(ctx, &lambda.ListFunctionsInput{
+			Marker:   marker,
 			MaxItems: aws.Int32(50),
 		})
 		if err != nil {
-			return fmt.Errorf("failed to ListFunctions: %w", err)
+			return nil, fmt.Errorf("failed to ListFunctions: %w", err)
 		}
 		for _, c := range res.Functions {
-			arn := app.functionArn(ctx, *c.FunctionName)
+			if opt.matchConfig(&c) {
+				functions = append(functions, &listedFunction{config: c})
+			}
+		}
+		if marker = res.NextMarker; marker == nil {
+			break
+		}
+	}
+
+	if opt.needTags() {
+		if err := app.fetchListedFunctionTags(ctx, functions, opt.Concurrency); err != nil {
+			return nil, err
+		}
+		filtered := functions[:0]
+		for _, f := range functions {
+			if opt.matchTags(f.tags) {
+				filtered = append(filtered, f)
+			}
+		}
+		functions = filtered
+	}
+	return functions, nil
+}
+
+// fetchListedFunctionTags fetches tags of the functions concurrently.
+func (app *App) fetchListedFunctionTags(ctx context.Context, functions []*listedFunction, concurrency int) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	sem := make(chan struct{}, concurrency)
+	for _, f := range functions {
+		f := f
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			arn := aws.ToString(f.config.FunctionArn)
 			log.Printf("[debug] listing tags of %s", arn)
 			res, err := app.lambda.ListTags(ctx, &lambda.ListTagsInput{
 				Resource: aws.String(arn),
 			})
 			if err != nil {
-				return fmt.Errorf("failed to list tags: %w", err)
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to list tags of %s: %w", arn, err)
+				}
+				mu.Unlock()
+				return
 			}
-			b, _ := marshalJSON(newFunctionFrom(&c, nil, res.Tags))
-			os.Stdout.Write(b)
+			f.tags = res.Tags
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+func writeListedFunctions(w io.Writer, functions []*listedFunction, opt *ListOption) error {
+	columns := opt.columns()
+	switch opt.Output {
+	case "json":
+		for _, f := range functions {
+			b, _ := marshalJSON(newFunctionFrom(&f.config, nil, f.tags))
+			w.Write(b)
 		}
-		if marker = res.NextMarker; marker == nil {
-			break
+	case "jsonl":
+		for _, f := range functions {
+			// keep the order of columns
+			fields := make([]string, 0, len(columns))
+			for _, c := range columns {
+				k, _ := json.Marshal(c)
+				v, _ := json.Marshal(listColumnValue(f, c))
+				fields = append(fields, string(k)+":"+string(v))
+			}
+			fmt.Fprintln(w, "{"+strings.Join(fields, ",")+"}")
+		}
+	case "tsv":
+		for _, f := range functions {
+			fmt.Fprintln(w, strings.Join(listRow(f, columns), "\t"))
+		}
+	case "table":
+		t := tablewriter.NewWriter(w)
+		t.SetHeader(columns)
+		t.SetAutoFormatHeaders(false)
+		for _, f := range functions {
+			t.Append(listRow(f, columns))
 		}
+		t.Render()
+	default:
+		return fmt.Errorf("unknown output format: %s", opt.Output)
 	}
 	return nil
 }
+
+func listRow(f *listedFunction, columns []string) []string {
+	row := make([]string, 0, len(columns))
+	for _, c := range columns {
+		row = append(row, listColumnString(listColumnValue(f, c)))
+	}
+	return row
+}
@@ -0,0 +1,162 @@
+package lambroll
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+func newFakeListFunctionsServer(t *testing.T) *httptest.Server {
+	pages := map[string]map[string]any{
+		"": {
+			"Functions": []map[string]any{
+				{"FunctionName": "app-a", "FunctionArn": "arn:aws:lambda:us-east-1:123456789012:function:app-a", "Runtime": "nodejs20.x", "PackageType": "Zip", "MemorySize": 128, "Timeout": 3},
+				{"FunctionName": "app-b", "FunctionArn": "arn:aws:lambda:us-east-1:123456789012:function:app-b", "PackageType": "Image", "MemorySize": 512, "Timeout": 30},
+			},
+			"NextMarker": "page2",
+		},
+		"page2": {
+			"Functions": []map[string]any{
+				{"FunctionName": "other", "FunctionArn": "arn:aws:lambda:us-east-1:123456789012:function:other", "Runtime": "python3.12", "PackageType": "Zip", "MemorySize": 256, "Timeout": 10},
+				{"FunctionName": "app-c", "FunctionArn": "arn:aws:lambda:us-east-1:123456789012:function:app-c", "Runtime": "python3.12", "PackageType": "Zip", "MemorySize": 1024, "Timeout": 60},
+			},
+		},
+	}
+	tags := map[string]map[string]string{
+		"app-a": {"Env": "prod", "Owner": "alice"},
+		"app-b": {"Env": "dev"},
+		"app-c": {"Env": "prod"},
+		"other": {},
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/2015-03-31/functions"):
+			page, ok := pages[r.URL.Query().Get("Marker")]
+			if !ok {
+				t.Errorf("unexpected marker %s", r.URL.Query().Get("Marker"))
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(page)
+		case strings.HasPrefix(r.URL.Path, "/2017-03-31/tags/"):
+			arn := strings.TrimPrefix(r.URL.Path, "/2017-03-31/tags/")
+			name := arn[strings.LastIndex(arn, ":")+1:]
+			json.NewEncoder(w).Encode(map[string]any{"Tags": tags[name]})
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestList(t *testing.T) {
+	ts := newFakeListFunctionsServer(t)
+	defer ts.Close()
+	app := &App{
+		lambda: lambda.New(lambda.Options{
+			Region:       "us-east-1",
+			BaseEndpoint: aws.String(ts.URL),
+			Credentials:  aws.AnonymousCredentials{},
+		}),
+	}
+	tests := []struct {
+		name     string
+		opt      ListOption
+		expected string
+	}{
+		{
+			name:     "all pages",
+			opt:      ListOption{Output: "tsv", Columns: []string{"FunctionName", "MemorySize"}},
+			expected: "app-a\t128\napp-b\t512\nother\t256\napp-c\t1024\n",
+		},
+		{
+			name:     "name and runtime",
+			opt:      ListOption{Name: "app-*", Runtime: []string{"python3.12"}, Output: "tsv", Columns: []string{"FunctionName", "Runtime"}},
+			expected: "app-c\tpython3.12\n",
+		},
+		{
+			name:     "package type",
+			opt:      ListOption{PackageType: "Image", Output: "tsv", Columns: []string{"FunctionName", "PackageType"}},
+			expected: "app-b\tImage\n",
+		},
+		{
+			name:     "tag",
+			opt:      ListOption{Tag: []string{"Env=prod"}, Output: "jsonl", Columns: []string{"FunctionName", "Tags.Owner", "Timeout"}},
+			expected: `{"FunctionName":"app-a","Tags.Owner":"alice","Timeout":3}` + "\n" + `{"FunctionName":"app-c","Tags.Owner":"","Timeout":60}` + "\n",
+		},
+		{
+			name:     "tag key",
+			opt:      ListOption{Tag: []string{"Owner"}, Output: "tsv", Columns: []string{"FunctionName"}},
+			expected: "app-a\n",
+		},
+		{
+			name:     "tags column",
+			opt:      ListOption{Name: "app-a", Tags: true, Output: "tsv", Columns: []string{"FunctionName"}},
+			expected: "app-a\t" + `{"Env":"prod","Owner":"alice"}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt := tt.opt
+			opt.Concurrency = 2
+			functions, err := app.listFunctions(context.Background(), &opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := writeListedFunctions(&buf, functions, &opt); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("unexpected output\n%s\nexpected\n%s", buf.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestListJSONIncludesTags(t *testing.T) {
+	ts := newFakeListFunctionsServer(t)
+	defer ts.Close()
+	app := &App{
+		lambda: lambda.New(lambda.Options{
+			Region:       "us-east-1",
+			BaseEndpoint: aws.String(ts.URL),
+			Credentials:  aws.AnonymousCredentials{},
+		}),
+	}
+	opt := &ListOption{Name: "app-a", Output: "json", Concurrency: 1}
+	functions, err := app.listFunctions(context.Background(), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeListedFunctions(&buf, functions, opt); err != nil {
+		t.Fatal(err)
+	}
+	var fn Function
+	if err := json.Unmarshal(buf.Bytes(), &fn); err != nil {
+		t.Fatal(err)
+	}
+	if fn.Tags["Owner"] != "alice" || fn.Tags["Env"] != "prod" {
+		t.Errorf("unexpected tags %v", fn.Tags)
+	}
+}
+
+func TestListOptionValidate(t *testing.T) {
+	opt := &ListOption{Columns: []string{"FunctionName", "Tags.Env"}}
+	if err := opt.validate(); err != nil {
+		t.Error(err)
+	}
+	opt = &ListOption{Columns: []string{"Name"}}
+	if err := opt.validate(); err == nil {
+		t.Error("expected error for unknown column")
+	}
+}
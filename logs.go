@@ -2,12 +2,22 @@ package lambroll
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
-	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
-	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/fatih/color"
+	"github.com/itchyny/gojq"
 )
 
 type LogsOption struct {
@@ -15,6 +25,56 @@ type LogsOption struct {
 	Follow        *bool   `help:"follow new logs" default:"false"`
 	Format        *string `help:"The format to display the logs" default:"detailed" enum:"detailed,short,json"`
 	FilterPattern *string `help:"The filter pattern to use"`
+	Qualifier     *string `help:"show only logs of the version or alias"`
+
+	Level     []string `help:"show only JSON log records of the levels (e.g. ERROR,WARN)"`
+	RequestID string   `name:"request-id" help:"show only log records of the request ID"`
+	Query     string   `help:"jq expression to filter or transform JSON log records"`
+}
+
+// logsPollInterval is an interval of polling new log events in follow mode.
+var logsPollInterval = 5 * time.Second
+
+var sinceDurationRegexp = regexp.MustCompile(`^(\d+)([smhdw])$`)
+
+// parseSince parses --since value as relative duration (e.g. 10m, 2h, 1d, 1w) or RFC3339 timestamp.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if m := sinceDurationRegexp.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		var unit time.Duration
+		switch m[2] {
+		case "s":
+			unit = time.Second
+		case "m":
+			unit = time.Minute
+		case "h":
+			unit = time.Hour
+		case "d":
+			unit = 24 * time.Hour
+		case "w":
+			unit = 7 * 24 * time.Hour
+		}
+		return now.Add(-time.Duration(n) * unit), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid since value: %s", s)
+}
+
+// logsTailer tails log events of the log group by FilterLogEvents API.
+type logsTailer struct {
+	client        *cloudwatchlogs.Client
+	logGroup      string
+	format        string
+	filterPattern *string
+	filter        *logsFilter
+	versions      []string
+	w             io.Writer
+
+	seen map[string]struct{}
 }
 
 func (app *App) Logs(ctx context.Context, opt *LogsOption) error {
@@ -23,34 +83,321 @@ func (app *App) Logs(ctx context.Context, opt *LogsOption) error {
 		return fmt.Errorf("failed to load function: %w", err)
 	}
 
-	logGroup := resolveLogGroup(fn)
-	command := []string{
-		"aws",
-		"--profile", app.profile,
-		"--region", app.awsConfig.Region,
-		"logs",
-		"tail",
-		logGroup,
+	since, err := parseSince(aws.ToString(opt.Since), time.Now())
+	if err != nil {
+		return err
 	}
-	if opt.Since != nil {
-		command = append(command, "--since", *opt.Since)
+	filter, err := newLogsFilter(opt)
+	if err != nil {
+		return err
 	}
-	if opt.Follow != nil && *opt.Follow {
-		command = append(command, "--follow")
+	t := &logsTailer{
+		client:        cloudwatchlogs.NewFromConfig(app.awsConfig),
+		logGroup:      resolveLogGroup(fn),
+		format:        aws.ToString(opt.Format),
+		filterPattern: opt.FilterPattern,
+		filter:        filter,
+		w:             os.Stdout,
 	}
-	if opt.Format != nil {
-		command = append(command, "--format", *opt.Format)
+	if t.filterPattern != nil && *t.filterPattern == "" {
+		t.filterPattern = nil
 	}
-	if opt.FilterPattern != nil && *opt.FilterPattern != "" {
-		command = append(command, "--filter-pattern", *opt.FilterPattern)
+	if opt.Qualifier != nil && *opt.Qualifier != "" {
+		if t.versions, err = app.resolveQualifierVersions(ctx, *fn.FunctionName, *opt.Qualifier); err != nil {
+			return err
+		}
 	}
-	bin, err := exec.LookPath(command[0])
+	log.Printf("[debug] tailing logs of %s since %s", t.logGroup, since.Format(time.RFC3339))
+	return t.tail(ctx, since, aws.ToBool(opt.Follow))
+}
+
+func (t *logsTailer) tail(ctx context.Context, since time.Time, follow bool) error {
+	t.seen = make(map[string]struct{})
+	start := since.UnixMilli()
+	for {
+		last, err := t.fetch(ctx, start)
+		if err != nil {
+			return err
+		}
+		if !follow {
+			return nil
+		}
+		if last > start {
+			// events at the same timestamp may be returned again. they are deduplicated by event ID.
+			start = last
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(logsPollInterval):
+		}
+	}
+}
+
+// fetch writes log events since start (unix milli) and returns the timestamp of the last event.
+func (t *logsTailer) fetch(ctx context.Context, start int64) (int64, error) {
+	last := start
+	seen := make(map[string]struct{})
+	p := cloudwatchlogs.NewFilterLogEventsPaginator(t.client, &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:  aws.String(t.logGroup),
+		StartTime:     aws.Int64(start),
+		FilterPattern: t.filterPattern,
+		Interleaved:   aws.Bool(true),
+	})
+	for p.HasMorePages() {
+		res, err := p.NextPage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return last, nil
+			}
+			return last, fmt.Errorf("failed to filter log events of %s: %w", t.logGroup, err)
+		}
+		for _, ev := range res.Events {
+			id := aws.ToString(ev.EventId)
+			seen[id] = struct{}{}
+			if _, ok := t.seen[id]; ok {
+				continue
+			}
+			if ts := aws.ToInt64(ev.Timestamp); ts > last {
+				last = ts
+			}
+			if !t.matchVersion(aws.ToString(ev.LogStreamName)) {
+				continue
+			}
+			t.write(ev)
+		}
+	}
+	t.seen = seen
+	return last, nil
+}
+
+// matchVersion reports whether the log stream belongs to the versions.
+// Lambda log stream names are formatted as "YYYY/MM/DD/[version]id".
+func (t *logsTailer) matchVersion(logStreamName string) bool {
+	return matchLogStreamVersion(logStreamName, t.versions)
+}
+
+func matchLogStreamVersion(logStreamName string, versions []string) bool {
+	if len(versions) == 0 {
+		return true
+	}
+	for _, v := range versions {
+		if strings.Contains(logStreamName, "/["+v+"]") {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveQualifierVersions resolves the qualifier to the versions.
+// An alias is resolved to its version and the additional versions by routing config.
+func (app *App) resolveQualifierVersions(ctx context.Context, name, qualifier string) ([]string, error) {
+	if qualifier == versionLatest {
+		return []string{qualifier}, nil
+	}
+	if _, err := strconv.ParseInt(qualifier, 10, 64); err == nil {
+		return []string{qualifier}, nil
+	}
+	res, err := app.lambda.GetAlias(ctx, &lambda.GetAliasInput{
+		FunctionName: aws.String(name),
+		Name:         aws.String(qualifier),
+	})
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to get alias %s: %w", qualifier, err)
+	}
+	versions := []string{aws.ToString(res.FunctionVersion)}
+	if rc := res.RoutingConfig; rc != nil && len(rc.AdditionalVersionWeights) > 0 {
+		primary := 1.0
+		for _, w := range rc.AdditionalVersionWeights {
+			primary -= w
+		}
+		log.Printf("[info] alias %s routes %.0f%% to version %s", qualifier, primary*100, versions[0])
+		for v, w := range rc.AdditionalVersionWeights {
+			log.Printf("[info] alias %s routes %.0f%% to version %s", qualifier, w*100, v)
+			versions = append(versions, v)
+		}
+	} else {
+		log.Printf("[info] alias %s points to version %s", qualifier, versions[0])
+	}
+	return versions, nil
+}
+
+func (t *logsTailer) write(ev types.FilteredLogEvent) {
+	ts := time.UnixMilli(aws.ToInt64(ev.Timestamp)).Local()
+	msg := strings.TrimRight(aws.ToString(ev.Message), "\n")
+	msgs := []string{msg}
+	if t.filter != nil {
+		var err error
+		if msgs, err = t.filter.apply(msg); err != nil {
+			log.Printf("[warn] %s", err)
+			return
+		}
+	}
+	for _, msg := range msgs {
+		switch t.format {
+		case "short":
+			fmt.Fprintln(t.w, color.GreenString(ts.Format("2006-01-02T15:04:05")), msg)
+		case "json":
+			fmt.Fprintln(t.w, color.GreenString(ts.Format("2006-01-02T15:04:05")), prettyLogRecord(msg))
+		default:
+			fmt.Fprintln(t.w,
+				color.GreenString(ts.Format("2006-01-02T15:04:05.000000-07:00")),
+				color.CyanString(aws.ToString(ev.LogStreamName)),
+				msg,
+			)
+		}
 	}
-	log.Println("[debug] invoking command", strings.Join(command, " "))
-	if err := syscall.Exec(bin, command, os.Environ()); err != nil {
-		return fmt.Errorf("failed to invoke aws logs tail: %w", err)
+}
+
+// logsFilter filters log records by level, request ID and jq query.
+type logsFilter struct {
+	levels    []string
+	requestID string
+	query     *gojq.Code
+}
+
+func newLogsFilter(opt *LogsOption) (*logsFilter, error) {
+	f := &logsFilter{
+		requestID: opt.RequestID,
+	}
+	for _, l := range opt.Level {
+		f.levels = append(f.levels, strings.ToUpper(l))
+	}
+	if opt.Query != "" {
+		q, err := gojq.Parse(opt.Query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse query: %s %w", opt.Query, err)
+		}
+		if f.query, err = gojq.Compile(q); err != nil {
+			return nil, fmt.Errorf("failed to compile query: %s %w", opt.Query, err)
+		}
+	}
+	if len(f.levels) == 0 && f.requestID == "" && f.query == nil {
+		return nil, nil
+	}
+	return f, nil
+}
+
+// apply returns the log messages to display. An empty result means the record is filtered out.
+func (f *logsFilter) apply(msg string) ([]string, error) {
+	record, ok := parseLogRecord(msg)
+	if !ok {
+		// text log record
+		if f.query != nil || len(f.levels) > 0 && !f.matchTextLevel(msg) {
+			return nil, nil
+		}
+		if f.requestID != "" && !strings.Contains(msg, f.requestID) {
+			return nil, nil
+		}
+		return []string{msg}, nil
+	}
+
+	if len(f.levels) > 0 {
+		level, _ := record["level"].(string)
+		if !f.matchLevel(level) {
+			return nil, nil
+		}
+	}
+	if f.requestID != "" && logRecordRequestID(record) != f.requestID {
+		return nil, nil
+	}
+	if f.query == nil {
+		return []string{msg}, nil
+	}
+
+	var msgs []string
+	iter := f.query.Run(record)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		switch v := v.(type) {
+		case error:
+			return nil, fmt.Errorf("failed to run query: %w", v)
+		case nil:
+			// filtered out
+		case bool:
+			if v {
+				msgs = append(msgs, msg)
+			}
+		case string:
+			msgs = append(msgs, v)
+		default:
+			b, _ := json.Marshal(v)
+			msgs = append(msgs, string(b))
+		}
+	}
+	return msgs, nil
+}
+
+func (f *logsFilter) matchLevel(level string) bool {
+	for _, l := range f.levels {
+		if strings.EqualFold(l, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTextLevel matches the level of text format logs ("timestamp\trequestId\tLEVEL\tmessage").
+func (f *logsFilter) matchTextLevel(msg string) bool {
+	for _, field := range strings.SplitN(msg, "\t", 4) {
+		if f.matchLevel(field) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseLogRecord(msg string) (map[string]any, bool) {
+	if !strings.HasPrefix(msg, "{") {
+		return nil, false
+	}
+	var record map[string]any
+	if err := json.Unmarshal([]byte(msg), &record); err != nil {
+		return nil, false
+	}
+	return record, true
+}
+
+// logRecordRequestID returns the request ID of the application log or platform log record.
+func logRecordRequestID(record map[string]any) string {
+	if id, ok := record["requestId"].(string); ok {
+		return id
+	}
+	if r, ok := record["record"].(map[string]any); ok {
+		if id, ok := r["requestId"].(string); ok {
+			return id
+		}
+	}
+	return ""
+}
+
+// prettyLogRecord indents a JSON log record and colorizes its level.
+func prettyLogRecord(msg string) string {
+	record, ok := parseLogRecord(msg)
+	if !ok {
+		return msg
+	}
+	b, _ := json.MarshalIndent(record, "", "  ")
+	level, _ := record["level"].(string)
+	colorize := levelColor(level)
+	if colorize == nil {
+		return string(b)
+	}
+	lv, _ := json.Marshal(level)
+	return strings.Replace(string(b), `"level": `+string(lv), `"level": `+colorize(string(lv)), 1)
+}
+
+func levelColor(level string) func(string, ...interface{}) string {
+	switch strings.ToUpper(level) {
+	case "FATAL", "ERROR":
+		return color.RedString
+	case "WARN", "WARNING":
+		return color.YellowString
+	case "DEBUG", "TRACE":
+		return color.HiBlackString
 	}
 	return nil
 }
@@ -0,0 +1,186 @@
+package lambroll
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/fatih/color"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 3, 14, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"30s":                  now.Add(-30 * time.Second),
+		"10m":                  now.Add(-10 * time.Minute),
+		"2h":                   now.Add(-2 * time.Hour),
+		"1d":                   now.Add(-24 * time.Hour),
+		"1w":                   now.Add(-7 * 24 * time.Hour),
+		"2024-03-14T10:00:00Z": time.Date(2024, 3, 14, 10, 0, 0, 0, time.UTC),
+	}
+	for s, expected := range cases {
+		got, err := parseSince(s, now)
+		if err != nil {
+			t.Errorf("failed to parse %s: %s", s, err)
+			continue
+		}
+		if !got.Equal(expected) {
+			t.Errorf("unexpected since %s: got %s expected %s", s, got, expected)
+		}
+	}
+	if _, err := parseSince("10x", now); err == nil {
+		t.Error("invalid since must be failed")
+	}
+}
+
+// newFakeLogsServer returns a fake CloudWatch Logs server which responds FilterLogEvents with events.
+func newFakeLogsServer(t *testing.T, events []map[string]any) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if target := r.Header.Get("X-Amz-Target"); target != "Logs_20140328.FilterLogEvents" {
+			t.Errorf("unexpected target %s", target)
+		}
+		var in struct {
+			LogGroupName string `json:"logGroupName"`
+			StartTime    int64  `json:"startTime"`
+		}
+		json.NewDecoder(r.Body).Decode(&in)
+		var res []map[string]any
+		for _, ev := range events {
+			if ev["timestamp"].(int64) >= in.StartTime {
+				res = append(res, ev)
+			}
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		json.NewEncoder(w).Encode(map[string]any{"events": res})
+	}))
+}
+
+func TestLogsTailer(t *testing.T) {
+	color.NoColor = true
+	since := time.Date(2024, 3, 14, 12, 0, 0, 0, time.UTC)
+	events := []map[string]any{
+		{"eventId": "1", "timestamp": since.UnixMilli(), "logStreamName": "2024/03/14/[1]abc", "message": "START RequestId: xxx\n"},
+		{"eventId": "2", "timestamp": since.UnixMilli() + 1, "logStreamName": "2024/03/14/[1]abc", "message": `{"level":"INFO","msg":"hello"}` + "\n"},
+	}
+	ts := newFakeLogsServer(t, events)
+	defer ts.Close()
+
+	client := cloudwatchlogs.New(cloudwatchlogs.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(ts.URL),
+		Credentials:  aws.AnonymousCredentials{},
+	})
+	for format, expected := range map[string]string{
+		"detailed": "2024/03/14/[1]abc START RequestId: xxx",
+		"short":    "START RequestId: xxx",
+		"json":     "\"level\": \"INFO\",",
+	} {
+		var buf bytes.Buffer
+		tailer := &logsTailer{
+			client:   client,
+			logGroup: "/aws/lambda/test",
+			format:   format,
+			w:        &buf,
+		}
+		if err := tailer.tail(context.Background(), since, false); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("unexpected output format %s: %s", format, buf.String())
+		}
+	}
+
+	// follow mode must not print duplicated events
+	logsPollInterval = 10 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var buf bytes.Buffer
+	tailer := &logsTailer{client: client, logGroup: "/aws/lambda/test", format: "short", w: &buf}
+	if err := tailer.tail(ctx, since, true); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Errorf("unexpected lines %d: %s", n, buf.String())
+	}
+}
+
+func TestLogsTailerMatchVersion(t *testing.T) {
+	tailer := &logsTailer{versions: []string{"2", "$LATEST"}}
+	for name, expected := range map[string]bool{
+		"2024/03/14/[2]0123456789abcdef":       true,
+		"2024/03/14/[$LATEST]0123456789abcdef": true,
+		"2024/03/14/[12]0123456789abcdef":      false,
+		"2024/03/14/[1]0123456789abcdef":       false,
+	} {
+		if got := tailer.matchVersion(name); got != expected {
+			t.Errorf("matchVersion(%s) expected %v got %v", name, expected, got)
+		}
+	}
+}
+
+func TestLogsFilter(t *testing.T) {
+	records := []string{
+		`{"timestamp":"2024-03-14T12:00:00Z","level":"INFO","requestId":"aaa","message":"hello"}`,
+		`{"timestamp":"2024-03-14T12:00:01Z","level":"ERROR","requestId":"bbb","message":"failed","code":500}`,
+		`{"time":"2024-03-14T12:00:02Z","type":"platform.start","record":{"requestId":"bbb","version":"1"}}`,
+		"2024-03-14T12:00:03Z\tbbb\tWARN\ttext message",
+		"START RequestId: aaa Version: 1",
+	}
+	cases := []struct {
+		opt      LogsOption
+		expected []string
+	}{
+		{
+			opt:      LogsOption{Level: []string{"error", "warn"}},
+			expected: []string{records[1], records[3]},
+		},
+		{
+			opt:      LogsOption{RequestID: "bbb"},
+			expected: []string{records[1], records[2], records[3]},
+		},
+		{
+			opt:      LogsOption{RequestID: "aaa"},
+			expected: []string{records[0], records[4]},
+		},
+		{
+			opt:      LogsOption{Query: `.code == 500`},
+			expected: []string{records[1]},
+		},
+		{
+			opt:      LogsOption{Query: `select(.level == "INFO") | .message`},
+			expected: []string{"hello"},
+		},
+		{
+			opt:      LogsOption{RequestID: "bbb", Query: `.record.version`},
+			expected: []string{"1"},
+		},
+	}
+	for i, c := range cases {
+		f, err := newLogsFilter(&c.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, r := range records {
+			msgs, err := f.apply(r)
+			if err != nil {
+				t.Errorf("case %d: %s", i, err)
+			}
+			got = append(got, msgs...)
+		}
+		if strings.Join(got, "\n") != strings.Join(c.expected, "\n") {
+			t.Errorf("case %d: unexpected records\n%s", i, strings.Join(got, "\n"))
+		}
+	}
+
+	if f, _ := newLogsFilter(&LogsOption{}); f != nil {
+		t.Error("filter must be nil without any conditions")
+	}
+}
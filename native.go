@@ -0,0 +1,160 @@
+package lambroll
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/fujiwara/tfstate-lookup/tfstate"
+	"github.com/google/go-jsonnet"
+	"github.com/google/go-jsonnet/ast"
+)
+
+// jsonnetNativeFunctions returns the lookup functions for Jsonnet.
+// They are called by std.native('name')(args) in Jsonnet files.
+func (app *App) jsonnetNativeFunctions(ctx context.Context) []*jsonnet.NativeFunction {
+	funcs := []*jsonnet.NativeFunction{
+		{
+			Name:   "env",
+			Params: ast.Identifiers{"name", "default"},
+			Func: func(args []any) (any, error) {
+				name, ok := args[0].(string)
+				if !ok {
+					return nil, fmt.Errorf("env: name must be a string")
+				}
+				if v, ok := os.LookupEnv(name); ok && v != "" {
+					return v, nil
+				}
+				return args[1], nil
+			},
+		},
+		{
+			Name:   "must_env",
+			Params: ast.Identifiers{"name"},
+			Func: func(args []any) (any, error) {
+				name, ok := args[0].(string)
+				if !ok {
+					return nil, fmt.Errorf("must_env: name must be a string")
+				}
+				if v, ok := os.LookupEnv(name); ok {
+					return v, nil
+				}
+				return nil, fmt.Errorf("environment variable %s is not defined", name)
+			},
+		},
+	}
+
+	if app.secrets != nil {
+		funcs = append(funcs,
+			&jsonnet.NativeFunction{
+				Name:   "ssm",
+				Params: ast.Identifiers{"name"},
+				Func: func(args []any) (any, error) {
+					name, ok := args[0].(string)
+					if !ok {
+						return nil, fmt.Errorf("ssm: name must be a string")
+					}
+					p, err := app.secrets.getParameter(ctx, name)
+					if err != nil {
+						return nil, err
+					}
+					if p.Type != ssmtypes.ParameterTypeStringList {
+						return aws.ToString(p.Value), nil
+					}
+					// index the array in Jsonnet instead of the index argument of the template function
+					values := strings.Split(aws.ToString(p.Value), ",")
+					list := make([]any, 0, len(values))
+					for _, v := range values {
+						list = append(list, v)
+					}
+					return list, nil
+				},
+			},
+			&jsonnet.NativeFunction{
+				Name:   "ssm_by_path",
+				Params: ast.Identifiers{"path"},
+				Func: func(args []any) (any, error) {
+					path, ok := args[0].(string)
+					if !ok {
+						return nil, fmt.Errorf("ssm_by_path: path must be a string")
+					}
+					values, err := app.secrets.lookupPath(ctx, path)
+					if err != nil {
+						return nil, err
+					}
+					obj := make(map[string]any, len(values))
+					for k, v := range values {
+						obj[k] = v
+					}
+					return obj, nil
+				},
+			},
+			&jsonnet.NativeFunction{
+				Name:   "secretsmanager",
+				Params: ast.Identifiers{"secretId", "key"},
+				Func: func(args []any) (any, error) {
+					id, ok1 := args[0].(string)
+					key, ok2 := args[1].(string)
+					if !ok1 || !ok2 {
+						return nil, fmt.Errorf("secretsmanager: secretId and key must be strings")
+					}
+					// native functions have fixed arity. '' means no key as same as the template function without the key
+					if key == "" {
+						return app.secrets.lookupSecret(ctx, id)
+					}
+					return app.secrets.lookupSecret(ctx, id, key)
+				},
+			},
+		)
+	}
+
+	prefixes := make([]string, 0, len(app.tfstates))
+	for prefix := range app.tfstates {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		funcs = append(funcs, tfstateNativeFunction(ctx, prefix+"tfstate", app.tfstates[prefix]))
+	}
+	return funcs
+}
+
+// tfstateNativeFunction returns a native function to lookup the value in tfstate.
+// The tfstate is read at the first call.
+func tfstateNativeFunction(ctx context.Context, name, loc string) *jsonnet.NativeFunction {
+	var (
+		once  sync.Once
+		state *tfstate.TFState
+		rerr  error
+	)
+	return &jsonnet.NativeFunction{
+		Name:   name,
+		Params: ast.Identifiers{"address"},
+		Func: func(args []any) (any, error) {
+			addr, ok := args[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: address must be a string", name)
+			}
+			once.Do(func() {
+				state, rerr = tfstate.ReadURL(ctx, loc)
+			})
+			if rerr != nil {
+				return nil, fmt.Errorf("failed to read tfstate %s: %w", loc, rerr)
+			}
+			addr = strings.ReplaceAll(addr, "'", "\"")
+			obj, err := state.Lookup(addr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to lookup %s in tfstate: %w", addr, err)
+			}
+			if obj.Value == nil {
+				return nil, fmt.Errorf("%s is not found in tfstate", addr)
+			}
+			return obj.Value, nil
+		},
+	}
+}
@@ -0,0 +1,64 @@
+package lambroll
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJsonnetNativeFunctions(t *testing.T) {
+	t.Setenv("NATIVE_STAGE", "prod")
+	t.Setenv("NATIVE_VERSION", "v1.2.3")
+	path := "test/terraform.tfstate"
+	app, err := New(context.Background(), &Option{
+		TFState: &path,
+		PrefixedTFState: map[string]string{
+			"prefix1_": "test/terraform_1.tfstate",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.secrets = newSecretLookup(newFakeSSM())
+
+	fn, err := app.loadFunction("test/native/function.jsonnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := aws.ToString(fn.FunctionName); name != "hello-prod" {
+		t.Errorf("unexpected FunctionName %s", name)
+	}
+	if m := aws.ToInt32(fn.MemorySize); m != 1024 {
+		t.Errorf("unexpected MemorySize %d", m)
+	}
+	if role := aws.ToString(fn.Role); role != "arn:aws:iam::123456789012:role/test_lambda_role" {
+		t.Errorf("unexpected Role %s", role)
+	}
+	expected := map[string]string{
+		"ROLE_1":      "arn:aws:iam::123456789012:role/test_lambda_role_1",
+		"API_KEY":     "my-api-key-0123",
+		"SUBNET":      "subnet-a",
+		"VERSION":     "v1.2.3",
+		"DB_HOST":     "db.example.com",
+		"DB_PASSWORD": "s3cr3t-password",
+	}
+	if diff := cmp.Diff(expected, fn.Environment.Variables); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestJsonnetNativeFunctionsError(t *testing.T) {
+	app, err := New(context.Background(), &Option{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.loadFunction("test/native/function.jsonnet"); err == nil {
+		t.Error("expected error for undefined tfstate function")
+	}
+	vm := app.jsonnetVM("test.jsonnet")
+	if _, err := vm.EvaluateAnonymousSnippet("test.jsonnet", `std.native('must_env')('NATIVE_UNDEFINED_ENV')`); err == nil {
+		t.Error("expected error for undefined environment variable")
+	}
+}
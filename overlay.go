@@ -0,0 +1,175 @@
+package lambroll
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+)
+
+// overlayFilePath returns the path of the overlay file for env.
+// For function.json(net) and env "prod", function.prod.json or function.prod.jsonnet.
+// It returns an empty string when no overlay file exists.
+func overlayFilePath(path, env string) string {
+	if env == "" {
+		return ""
+	}
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, ext := range []string{".json", ".jsonnet"} {
+		p := base + "." + env + ext
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// applyOverlay merges the overlay file on top of the rendered base definition.
+// A JSON overlay is deep-merged, and a Jsonnet overlay is evaluated as a mixin of the base definition.
+// It returns the merged JSON and the origins (JSON path -> file) of the merged values.
+func (app *App) applyOverlay(path string, src []byte, overlayPath string) ([]byte, map[string]string, error) {
+	var base any
+	if err := json.Unmarshal(src, &base); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	var merged any
+	switch filepath.Ext(overlayPath) {
+	case ".jsonnet":
+		// the result of the mixin is used as is, so that the overlay can replace
+		// the objects by `key:` or merge them by `key+:` and refer to the base values by super.
+		// Only the overlay is rendered as a template because src has been rendered already.
+		abs, err := filepath.Abs(overlayPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		rendered, err := app.loader.ReadWithEnv(overlayPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		vm := app.jsonnetVM(overlayPath)
+		vm.Importer(&overlayImporter{
+			path:     abs,
+			contents: jsonnet.MakeContentsRaw(rendered),
+			Importer: app.jsonnetImporter(overlayPath),
+		})
+		name, _ := json.Marshal(abs)
+		snippet := fmt.Sprintf("(%s) + (import %s)", src, name)
+		jsonStr, err := vm.EvaluateAnonymousSnippet(overlayPath, snippet)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := json.Unmarshal([]byte(jsonStr), &merged); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %w", overlayPath, err)
+		}
+	default:
+		b, err := app.loader.ReadWithEnv(overlayPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		var overlay any
+		if err := json.Unmarshal(b, &overlay); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %w", overlayPath, err)
+		}
+		merged = deepMerge(base, overlay)
+	}
+	b, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal merged definition: %w", err)
+	}
+	origins := make(map[string]string)
+	layerOrigins(base, merged, "", path, overlayPath, origins)
+	log.Printf("[debug] merged %s on %s", overlayPath, path)
+	return b, origins, nil
+}
+
+// overlayImporter imports the rendered overlay for its path, and other files by Importer.
+type overlayImporter struct {
+	path     string
+	contents jsonnet.Contents
+	jsonnet.Importer
+}
+
+func (i *overlayImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	if importedPath == i.path {
+		return i.contents, i.path, nil
+	}
+	return i.Importer.Import(importedFrom, importedPath)
+}
+
+// deepMerge merges src into dst recursively.
+// Objects are merged by keys, other values (including arrays) are replaced.
+// A null value in src removes the key from dst.
+func deepMerge(dst, src any) any {
+	d, ok1 := dst.(map[string]any)
+	s, ok2 := src.(map[string]any)
+	if !ok1 || !ok2 {
+		return src
+	}
+	merged := make(map[string]any, len(d))
+	for k, v := range d {
+		merged[k] = v
+	}
+	for k, v := range s {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		if dv, ok := merged[k]; ok {
+			merged[k] = deepMerge(dv, v)
+		} else {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// layerOrigins records which file each leaf value of merged came from.
+func layerOrigins(base, merged any, prefix, basePath, overlayPath string, origins map[string]string) {
+	if m, ok := merged.(map[string]any); ok {
+		b, _ := base.(map[string]any)
+		for k, v := range m {
+			layerOrigins(b[k], v, prefix+"."+k, basePath, overlayPath, origins)
+		}
+		return
+	}
+	if reflect.DeepEqual(base, merged) {
+		origins[prefix] = basePath
+	} else {
+		origins[prefix] = overlayPath
+	}
+}
+
+// changedPaths returns the JSON paths of leaf values in x which differ from y.
+func changedPaths(x, y any, prefix string) []string {
+	if m, ok := x.(map[string]any); ok {
+		n, _ := y.(map[string]any)
+		var paths []string
+		for k, v := range m {
+			paths = append(paths, changedPaths(v, n[k], prefix+"."+k)...)
+		}
+		sort.Strings(paths)
+		return paths
+	}
+	if reflect.DeepEqual(x, y) {
+		return nil
+	}
+	return []string{prefix}
+}
+
+// definitionLayers returns the origins of the values in the definition file merged with the overlay.
+func (app *App) definitionLayers(path string, defaults []string) map[string]string {
+	if path == "" {
+		p, err := findDefinitionFile("", defaults)
+		if err != nil {
+			return nil
+		}
+		path = p
+	}
+	return app.layers[path]
+}
@@ -0,0 +1,168 @@
+package lambroll
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/google/go-cmp/cmp"
+)
+
+func newOverlayTestApp(t *testing.T, env string) *App {
+	t.Helper()
+	t.Setenv("OVERLAY_ENV", "production")
+	app, err := New(context.Background(), &Option{
+		Function: "test/overlay/function.json",
+		Env:      env,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func TestOverlayJSON(t *testing.T) {
+	app := newOverlayTestApp(t, "prod")
+	fn, err := app.loadFunction(app.functionFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := aws.ToInt32(fn.MemorySize); m != 1024 {
+		t.Errorf("unexpected MemorySize %d", m)
+	}
+	if tm := aws.ToInt32(fn.Timeout); tm != 5 {
+		t.Errorf("unexpected Timeout %d", tm)
+	}
+	if diff := cmp.Diff(map[string]string{"ENV": "production"}, fn.Environment.Variables); diff != "" {
+		t.Error(diff)
+	}
+
+	layers := app.definitionLayers(app.functionFilePath, DefaultFunctionFilenames)
+	expected := map[string]string{
+		".MemorySize":                "test/overlay/function.prod.json",
+		".Environment.Variables.ENV": "test/overlay/function.prod.json",
+		".Timeout":                   "test/overlay/function.json",
+		".Tags.Project":              "test/overlay/function.json",
+	}
+	for path, file := range expected {
+		if layers[path] != file {
+			t.Errorf("layer of %s: expected %s, got %s", path, file, layers[path])
+		}
+	}
+	if _, ok := layers[".Environment.Variables.LOG_LEVEL"]; ok {
+		t.Error("LOG_LEVEL must be removed")
+	}
+}
+
+func TestOverlayJsonnetMixin(t *testing.T) {
+	app := newOverlayTestApp(t, "stg")
+	fn, err := app.loadFunction(app.functionFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tm := aws.ToInt32(fn.Timeout); tm != 10 {
+		t.Errorf("unexpected Timeout %d", tm)
+	}
+	if diff := cmp.Diff(map[string]string{"ENV": "stg", "LOG_LEVEL": "debug"}, fn.Environment.Variables); diff != "" {
+		t.Error(diff)
+	}
+	// Tags is replaced by the mixin without +:
+	if diff := cmp.Diff(map[string]string{"Stage": "stg"}, fn.Tags); diff != "" {
+		t.Error(diff)
+	}
+	layers := app.definitionLayers(app.functionFilePath, DefaultFunctionFilenames)
+	if _, ok := layers[".Tags.Project"]; ok {
+		t.Error("Tags.Project must be removed")
+	}
+	if layers[".Tags.Stage"] != "test/overlay/function.stg.jsonnet" {
+		t.Errorf("unexpected layer of Tags.Stage %s", layers[".Tags.Stage"])
+	}
+}
+
+func TestOverlayJsonnetRenderedOnce(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"function.json":        `{"FunctionName": "hello", "Description": "{{ must_env ` + "`OVERLAY_DESC`" + ` }}"}`,
+		"function.stg.jsonnet": `{ Description: super.Description + ' {{ must_env ` + "`OVERLAY_STAGE`" + ` }}' }`,
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the resolved value in the base must not be rendered again
+	t.Setenv("OVERLAY_DESC", "{{ must_env `OVERLAY_UNDEFINED` }}")
+	t.Setenv("OVERLAY_STAGE", "stg")
+	app, err := New(context.Background(), &Option{
+		Function: filepath.Join(dir, "function.json"),
+		Env:      "stg",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn, err := app.loadFunction(app.functionFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := aws.ToString(fn.Description); d != "{{ must_env `OVERLAY_UNDEFINED` }} stg" {
+		t.Errorf("unexpected Description %s", d)
+	}
+}
+
+func TestOverlayOnlyForFunctionDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "expect.json")
+	if err := os.WriteFile(path, []byte(`[{"StatusCode":200}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "expect.prod.json"), []byte(`[{"StatusCode":500}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	app := newOverlayTestApp(t, "prod")
+	es, err := app.loadInvokeExpectations(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(es) != 1 || aws.ToInt32(es[0].StatusCode) != 200 {
+		t.Errorf("invoke expectations must not be overlaid: %#v", es)
+	}
+}
+
+func TestOverlayNotFound(t *testing.T) {
+	app := newOverlayTestApp(t, "dev")
+	fn, err := app.loadFunction(app.functionFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := aws.ToInt32(fn.MemorySize); m != 128 {
+		t.Errorf("unexpected MemorySize %d", m)
+	}
+	if layers := app.definitionLayers(app.functionFilePath, DefaultFunctionFilenames); layers != nil {
+		t.Errorf("unexpected layers %v", layers)
+	}
+}
+
+func TestChangedPaths(t *testing.T) {
+	x := map[string]any{
+		"MemorySize": 1024.0,
+		"Timeout":    5.0,
+		"Environment": map[string]any{
+			"Variables": map[string]any{"ENV": "prod", "FOO": "bar"},
+		},
+		"Layers": []any{"a", "b"},
+	}
+	y := map[string]any{
+		"MemorySize": 128.0,
+		"Timeout":    5.0,
+		"Environment": map[string]any{
+			"Variables": map[string]any{"FOO": "bar"},
+		},
+		"Layers": []any{"a"},
+	}
+	expected := []string{".Environment.Variables.ENV", ".Layers", ".MemorySize"}
+	if diff := cmp.Diff(expected, changedPaths(x, y, "")); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -9,6 +9,7 @@ import (
 type RenderOption struct {
 	Jsonnet     bool   `default:"false" help:"render function.json as jsonnet"`
 	FunctionURL string `help:"render function-url definiton file" default:"" env:"LAMBROLL_FUNCTION_URL"`
+	Redact      bool   `default:"false" help:"mask the resolved secret values"`
 }
 
 // Invoke invokes function
@@ -34,6 +35,12 @@ func (app *App) Render(ctx context.Context, opt *RenderOption) error {
 		}
 	}
 
+	if opt.Redact {
+		b, err = app.redactJSON(b)
+		if err != nil {
+			return fmt.Errorf("failed to redact: %w", err)
+		}
+	}
 	if opt.Jsonnet {
 		b, err = jsonToJsonnet(b, app.functionFilePath)
 		if err != nil {
@@ -0,0 +1,340 @@
+package lambroll
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/olekukonko/tablewriter"
+)
+
+// RuntimesOption represents options for Runtimes()
+type RuntimesOption struct {
+	Files   []string `arg:"" optional:"" help:"function definition files to check with --local (default: --function or function.json)"`
+	Local   bool     `default:"false" help:"check the local function definitions instead of the functions in the account"`
+	Days    int      `default:"180" help:"report runtimes which will be deprecated within the days"`
+	All     bool     `default:"false" help:"report all functions including supported runtimes"`
+	Upgrade string   `default:"" help:"rewrite Runtime in the local function definitions to the runtime. 'latest' means the latest runtime of the same language"`
+	Output  string   `default:"table" enum:"table,json" help:"output format"`
+}
+
+// runtimeDeprecations represents deprecation dates of the Lambda runtimes.
+// The zero time means that the deprecation date has not been scheduled.
+// https://docs.aws.amazon.com/lambda/latest/dg/lambda-runtimes.html
+var runtimeDeprecations = map[string]time.Time{
+	"nodejs4.3":       runtimeDate("2020-03-05"),
+	"nodejs6.10":      runtimeDate("2019-08-12"),
+	"nodejs8.10":      runtimeDate("2020-03-06"),
+	"nodejs10.x":      runtimeDate("2021-07-30"),
+	"nodejs12.x":      runtimeDate("2023-03-31"),
+	"nodejs14.x":      runtimeDate("2023-12-04"),
+	"nodejs16.x":      runtimeDate("2024-06-12"),
+	"nodejs18.x":      runtimeDate("2025-09-01"),
+	"nodejs20.x":      runtimeDate("2026-04-30"),
+	"nodejs22.x":      runtimeDate("2027-04-30"),
+	"python2.7":       runtimeDate("2021-07-15"),
+	"python3.6":       runtimeDate("2022-07-18"),
+	"python3.7":       runtimeDate("2023-12-04"),
+	"python3.8":       runtimeDate("2024-10-14"),
+	"python3.9":       runtimeDate("2025-12-15"),
+	"python3.10":      runtimeDate("2026-06-30"),
+	"python3.11":      runtimeDate("2026-06-30"),
+	"python3.12":      runtimeDate("2028-10-31"),
+	"python3.13":      runtimeDate("2029-06-30"),
+	"ruby2.5":         runtimeDate("2021-07-30"),
+	"ruby2.7":         runtimeDate("2023-12-07"),
+	"ruby3.2":         runtimeDate("2026-03-31"),
+	"ruby3.3":         runtimeDate("2027-03-31"),
+	"ruby3.4":         runtimeDate("2028-03-31"),
+	"java8":           runtimeDate("2024-01-08"),
+	"java8.al2":       runtimeDate("2026-06-30"),
+	"java11":          runtimeDate("2026-06-30"),
+	"java17":          runtimeDate("2026-06-30"),
+	"java21":          runtimeDate("2029-06-30"),
+	"dotnetcore1.0":   runtimeDate("2019-07-30"),
+	"dotnetcore2.0":   runtimeDate("2019-05-30"),
+	"dotnetcore2.1":   runtimeDate("2022-01-05"),
+	"dotnetcore3.1":   runtimeDate("2023-04-03"),
+	"dotnet6":         runtimeDate("2024-12-20"),
+	"dotnet7":         runtimeDate("2024-05-14"),
+	"dotnet8":         runtimeDate("2026-11-10"),
+	"go1.x":           runtimeDate("2024-01-08"),
+	"provided":        runtimeDate("2024-01-08"),
+	"provided.al2":    runtimeDate("2026-06-30"),
+	"provided.al2023": {},
+}
+
+func runtimeDate(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+var runtimeFamilyPrefixRegexp = regexp.MustCompile(`^[a-z]+`)
+
+// runtimeFamily returns the language of the runtime. e.g. nodejs, python, dotnet, provided
+func runtimeFamily(runtime string) string {
+	f := runtimeFamilyPrefixRegexp.FindString(runtime)
+	if f == "go" {
+		// go1.x is superseded by the OS-only runtimes
+		return "provided"
+	}
+	return strings.TrimSuffix(f, "core") // dotnetcore -> dotnet
+}
+
+// latestRuntime returns the runtime of the same family which will be deprecated last.
+func latestRuntime(runtime string) string {
+	family := runtimeFamily(runtime)
+	var latest string
+	var latestDate time.Time
+	for r, d := range runtimeDeprecations {
+		if runtimeFamily(r) != family {
+			continue
+		}
+		switch {
+		case latest == "":
+		case latestDate.IsZero():
+			continue
+		case !d.IsZero() && !d.After(latestDate):
+			continue
+		}
+		latest, latestDate = r, d
+	}
+	return latest
+}
+
+const (
+	runtimeStatusDeprecated  = "deprecated"
+	runtimeStatusDeprecating = "deprecating"
+	runtimeStatusSupported   = "supported"
+	runtimeStatusUnknown     = "unknown"
+)
+
+// runtimeStatus returns the status and the deprecation date of the runtime at now.
+func runtimeStatus(runtime string, now time.Time, days int) (string, time.Time) {
+	d, ok := runtimeDeprecations[runtime]
+	switch {
+	case !ok:
+		return runtimeStatusUnknown, d
+	case d.IsZero():
+		return runtimeStatusSupported, d
+	case !now.Before(d):
+		return runtimeStatusDeprecated, d
+	case now.AddDate(0, 0, days).After(d):
+		return runtimeStatusDeprecating, d
+	}
+	return runtimeStatusSupported, d
+}
+
+// runtimeReport represents a function and the status of the runtime.
+type runtimeReport struct {
+	FunctionName    string `json:"FunctionName"`
+	Runtime         string `json:"Runtime"`
+	Status          string `json:"Status"`
+	DeprecationDate string `json:"DeprecationDate,omitempty"`
+	Latest          string `json:"Latest,omitempty"`
+	File            string `json:"File,omitempty"`
+}
+
+func newRuntimeReport(name, runtime string, now time.Time, days int) *runtimeReport {
+	status, date := runtimeStatus(runtime, now, days)
+	r := &runtimeReport{
+		FunctionName: name,
+		Runtime:      runtime,
+		Status:       status,
+		Latest:       latestRuntime(runtime),
+	}
+	if !date.IsZero() {
+		r.DeprecationDate = date.Format("2006-01-02")
+	}
+	return r
+}
+
+func (r *runtimeReport) needsUpgrade() bool {
+	return r.Status == runtimeStatusDeprecated || r.Status == runtimeStatusDeprecating
+}
+
+type runtimeReports []*runtimeReport
+
+func (rs runtimeReports) JSON() string {
+	b, _ := json.MarshalIndent(rs, "", "  ")
+	return string(b)
+}
+
+func (rs runtimeReports) Table(local bool) string {
+	buf := new(strings.Builder)
+	w := tablewriter.NewWriter(buf)
+	header := []string{"FunctionName", "Runtime", "Status", "Deprecation Date", "Latest"}
+	if local {
+		header = append(header, "File")
+	}
+	w.SetHeader(header)
+	for _, r := range rs {
+		row := []string{r.FunctionName, r.Runtime, r.Status, r.DeprecationDate, r.Latest}
+		if local {
+			row = append(row, r.File)
+		}
+		w.Append(row)
+	}
+	w.Render()
+	return buf.String()
+}
+
+// Runtimes reports functions on deprecated runtimes.
+func (app *App) Runtimes(ctx context.Context, opt *RuntimesOption) error {
+	if opt.Upgrade != "" && !opt.Local {
+		return fmt.Errorf("--upgrade requires --local")
+	}
+	now := time.Now()
+	var reports runtimeReports
+	var err error
+	if opt.Local {
+		reports, err = app.localRuntimeReports(opt, now)
+	} else {
+		reports, err = app.accountRuntimeReports(ctx, opt, now)
+	}
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(reports, func(i, j int) bool {
+		return reports[i].FunctionName < reports[j].FunctionName
+	})
+
+	filtered := runtimeReports{} // not nil to output [] in JSON
+	for _, r := range reports {
+		if opt.All || r.needsUpgrade() {
+			filtered = append(filtered, r)
+		}
+	}
+	switch opt.Output {
+	case "json":
+		fmt.Println(filtered.JSON())
+	case "table":
+		if len(filtered) == 0 {
+			log.Printf("[info] no functions on runtimes deprecated within %d days", opt.Days)
+			return nil
+		}
+		fmt.Print(filtered.Table(opt.Local))
+	}
+
+	if opt.Upgrade != "" && len(filtered) > 0 {
+		return upgradeRuntimes(filtered, opt.Upgrade)
+	}
+	return nil
+}
+
+func (app *App) accountRuntimeReports(ctx context.Context, opt *RuntimesOption, now time.Time) (runtimeReports, error) {
+	var reports runtimeReports
+	var marker *string
+	for {
+		res, err := app.lambda.ListFunctions(ctx, &lambda.ListFunctionsInput{
+			Marker:   marker,
+			MaxItems: aws.Int32(50),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list functions: %w", err)
+		}
+		for _, c := range res.Functions {
+			if c.Runtime == "" {
+				continue // Image
+			}
+			reports = append(reports, newRuntimeReport(aws.ToString(c.FunctionName), string(c.Runtime), now, opt.Days))
+		}
+		if marker = res.NextMarker; marker == nil {
+			break
+		}
+	}
+	return reports, nil
+}
+
+func (app *App) localRuntimeReports(opt *RuntimesOption, now time.Time) (runtimeReports, error) {
+	files := opt.Files
+	if len(files) == 0 {
+		p, err := findDefinitionFile(app.functionFilePath, DefaultFunctionFilenames)
+		if err != nil {
+			return nil, err
+		}
+		files = []string{p}
+	}
+	var reports runtimeReports
+	for _, f := range files {
+		fn, err := app.loadFunction(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load function %s: %w", f, err)
+		}
+		if fn.Runtime == "" {
+			log.Printf("[debug] %s has no Runtime", f)
+			continue
+		}
+		r := newRuntimeReport(aws.ToString(fn.FunctionName), string(fn.Runtime), now, opt.Days)
+		r.File = f
+		reports = append(reports, r)
+	}
+	return reports, nil
+}
+
+// upgradeRuntimes rewrites Runtime in the files of the reports.
+func upgradeRuntimes(reports runtimeReports, to string) error {
+	for _, r := range reports {
+		if !r.needsUpgrade() {
+			continue
+		}
+		target := to
+		if target == "latest" {
+			target = r.Latest
+		}
+		if runtimeFamily(target) != runtimeFamily(r.Runtime) {
+			return fmt.Errorf("cannot upgrade %s to %s in %s: different language", r.Runtime, target, r.File)
+		}
+		if s, _ := runtimeStatus(target, time.Now(), 0); s == runtimeStatusDeprecated {
+			return fmt.Errorf("cannot upgrade %s to %s in %s: %s is deprecated", r.Runtime, target, r.File, target)
+		}
+		src, err := os.ReadFile(r.File)
+		if err != nil {
+			return err
+		}
+		b, ok := rewriteRuntime(src, filepath.Ext(r.File), r.Runtime, target)
+		if !ok {
+			log.Printf("[warn] Runtime %q is not found as a literal in %s. rewrite it manually", r.Runtime, r.File)
+			continue
+		}
+		info, err := os.Stat(r.File)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(r.File, b, info.Mode()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", r.File, err)
+		}
+		log.Printf("[info] upgraded Runtime %s to %s in %s", r.Runtime, target, r.File)
+		if r.Runtime == "go1.x" {
+			log.Printf("[warn] %s requires the executable named bootstrap. build the binary as bootstrap for %s", target, r.File)
+		}
+	}
+	return nil
+}
+
+// rewriteRuntime rewrites the literal value of the Runtime field from to in the source of JSON or Jsonnet.
+// Other parts of the source (templates, comments and formatting) are kept.
+func rewriteRuntime(src []byte, ext string, from, to string) ([]byte, bool) {
+	var re *regexp.Regexp
+	if ext == ".jsonnet" || ext == ".libsonnet" {
+		re = regexp.MustCompile(`((?:\bRuntime|'Runtime'|"Runtime")\s*:{1,3}\s*['"])` + regexp.QuoteMeta(from) + `(['"])`)
+	} else {
+		re = regexp.MustCompile(`("Runtime"\s*:\s*")` + regexp.QuoteMeta(from) + `(")`)
+	}
+	if !re.Match(src) {
+		return src, false
+	}
+	return re.ReplaceAll(src, []byte("${1}"+to+"${2}")), true
+}
@@ -0,0 +1,111 @@
+package lambroll
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRuntimeStatus(t *testing.T) {
+	now := runtimeDate("2026-02-01")
+	tests := []struct {
+		runtime string
+		status  string
+		latest  string
+	}{
+		{"nodejs16.x", runtimeStatusDeprecated, "nodejs22.x"},
+		{"nodejs20.x", runtimeStatusDeprecating, "nodejs22.x"},
+		{"nodejs22.x", runtimeStatusSupported, "nodejs22.x"},
+		{"python3.9", runtimeStatusDeprecated, "python3.13"},
+		{"dotnetcore3.1", runtimeStatusDeprecated, "dotnet8"},
+		{"provided.al2", runtimeStatusDeprecating, "provided.al2023"},
+		{"provided.al2023", runtimeStatusSupported, "provided.al2023"},
+		{"go1.x", runtimeStatusDeprecated, "provided.al2023"},
+		{"provided", runtimeStatusDeprecated, "provided.al2023"},
+		{"nodejs99.x", runtimeStatusUnknown, "nodejs22.x"},
+	}
+	for _, tt := range tests {
+		r := newRuntimeReport("hello", tt.runtime, now, 180)
+		if r.Status != tt.status {
+			t.Errorf("%s: expected status %s, got %s", tt.runtime, tt.status, r.Status)
+		}
+		if r.Latest != tt.latest {
+			t.Errorf("%s: expected latest %s, got %s", tt.runtime, tt.latest, r.Latest)
+		}
+	}
+	if s, _ := runtimeStatus("nodejs20.x", now, 30); s != runtimeStatusSupported {
+		t.Errorf("nodejs20.x is not deprecating within 30 days: %s", s)
+	}
+	if s, _ := runtimeStatus("nodejs20.x", runtimeDate("2026-04-30").Add(time.Second), 0); s != runtimeStatusDeprecated {
+		t.Errorf("nodejs20.x is deprecated after the date: %s", s)
+	}
+}
+
+func TestRuntimeReportsJSONEmpty(t *testing.T) {
+	if s := (runtimeReports{}).JSON(); s != "[]" {
+		t.Errorf("unexpected JSON for no reports: %s", s)
+	}
+}
+
+func TestRewriteRuntime(t *testing.T) {
+	tests := []struct {
+		ext      string
+		src      string
+		expected string
+		ok       bool
+	}{
+		{
+			ext:      ".json",
+			src:      `{"FunctionName": "{{ must_env ` + "`NAME`" + ` }}", "Runtime" : "nodejs16.x", "Handler": "index.handler"}`,
+			expected: `{"FunctionName": "{{ must_env ` + "`NAME`" + ` }}", "Runtime" : "nodejs20.x", "Handler": "index.handler"}`,
+			ok:       true,
+		},
+		{
+			ext:      ".jsonnet",
+			src:      "{\n  Runtime: 'nodejs16.x',\n  Description: 'nodejs16.x function',\n}",
+			expected: "{\n  Runtime: 'nodejs20.x',\n  Description: 'nodejs16.x function',\n}",
+			ok:       true,
+		},
+		{
+			ext:      ".jsonnet",
+			src:      `{ "Runtime": "nodejs16.x" }`,
+			expected: `{ "Runtime": "nodejs20.x" }`,
+			ok:       true,
+		},
+		{
+			ext:      ".jsonnet",
+			src:      `local rt = std.extVar('runtime'); { Runtime: rt }`,
+			expected: `local rt = std.extVar('runtime'); { Runtime: rt }`,
+			ok:       false,
+		},
+	}
+	for _, tt := range tests {
+		b, ok := rewriteRuntime([]byte(tt.src), tt.ext, "nodejs16.x", "nodejs20.x")
+		if ok != tt.ok {
+			t.Errorf("%s: expected %v, got %v", tt.src, tt.ok, ok)
+		}
+		if string(b) != tt.expected {
+			t.Errorf("unexpected rewritten source %s", string(b))
+		}
+	}
+}
+
+func TestUpgradeRuntimesGo1x(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "function.json")
+	if err := os.WriteFile(file, []byte(`{"Runtime": "go1.x", "Handler": "main"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := newRuntimeReport("hello", "go1.x", runtimeDate("2026-02-01"), 180)
+	r.File = file
+	if err := upgradeRuntimes(runtimeReports{r}, "latest"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"Runtime": "provided.al2023", "Handler": "main"}`; string(b) != expected {
+		t.Errorf("unexpected upgraded source %s", string(b))
+	}
+}
@@ -0,0 +1,332 @@
+package lambroll
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// secretsManagerReferencePrefix is the prefix of SSM parameter names to refer to Secrets Manager secrets.
+const secretsManagerReferencePrefix = "/aws/reference/secretsmanager/"
+
+const redactedValue = "********"
+
+type ssmClient interface {
+	GetParameter(context.Context, *ssm.GetParameterInput, ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+	GetParametersByPath(context.Context, *ssm.GetParametersByPathInput, ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error)
+}
+
+// redactMinLength is the minimum length of secret values to redact.
+// Short values (e.g. "true", "1") may match unrelated parts of the output.
+const redactMinLength = 8
+
+// ssmLookup looks up a parameter for the ssm template function (github.com/fujiwara/ssm-lookup).
+type ssmLookup interface {
+	Lookup(ctx context.Context, name string, index ...int) (string, error)
+}
+
+// secretLookup looks up values from Parameter Store and Secrets Manager for template functions.
+// Parameters are cached across all definition files in one run. The cache is shared with
+// the ssm template function of ssm-lookup, so a parameter is fetched once.
+// Values of SecureString parameters and Secrets Manager secrets are recorded to redact them in the output.
+type secretLookup struct {
+	ssm   ssmClient
+	cache *sync.Map // parameter name -> *ssm.GetParameterOutput
+
+	mu      sync.Mutex
+	paths   map[string]map[string]string
+	secrets map[string]struct{}
+}
+
+func newSecretLookup(svc ssmClient) *secretLookup {
+	return &secretLookup{
+		ssm:     svc,
+		cache:   &sync.Map{},
+		paths:   make(map[string]map[string]string),
+		secrets: make(map[string]struct{}),
+	}
+}
+
+// FuncMap returns template functions ssm, ssm_by_path and secretsmanager.
+// The ssm function is resolved by lookup which must use the cache of s.
+func (s *secretLookup) FuncMap(ctx context.Context, lookup ssmLookup) template.FuncMap {
+	return template.FuncMap{
+		"ssm": func(name string, index ...int) (string, error) {
+			v, err := lookup.Lookup(ctx, name, index...)
+			if err != nil {
+				return "", fmt.Errorf("failed to lookup ssm parameter: %w", err)
+			}
+			if out, ok := s.cache.Load(name); ok {
+				s.recordParameter(out.(*ssm.GetParameterOutput).Parameter)
+			}
+			return v, nil
+		},
+		"ssm_by_path": func(path string) (map[string]string, error) {
+			v, err := s.lookupPath(ctx, path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to lookup ssm parameters by path: %w", err)
+			}
+			return v, nil
+		},
+		"secretsmanager": func(secretID string, key ...string) (string, error) {
+			v, err := s.lookupSecret(ctx, secretID, key...)
+			if err != nil {
+				return "", fmt.Errorf("failed to lookup secret: %w", err)
+			}
+			return v, nil
+		},
+	}
+}
+
+func (s *secretLookup) getParameter(ctx context.Context, name string) (*ssmtypes.Parameter, error) {
+	if out, ok := s.cache.Load(name); ok {
+		p := out.(*ssm.GetParameterOutput).Parameter
+		s.recordParameter(p)
+		return p, nil
+	}
+	res, err := s.ssm.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parameter %s: %w", name, err)
+	}
+	s.cache.Store(name, res)
+	s.recordParameter(res.Parameter)
+	return res.Parameter, nil
+}
+
+// lookupPath returns the values of parameters under the path recursively.
+// The keys are the names of parameters relative to the path.
+func (s *secretLookup) lookupPath(ctx context.Context, path string) (map[string]string, error) {
+	s.mu.Lock()
+	v, ok := s.paths[path]
+	s.mu.Unlock()
+	if ok {
+		return v, nil
+	}
+	values := make(map[string]string)
+	p := ssm.NewGetParametersByPathPaginator(s.ssm, &ssm.GetParametersByPathInput{
+		Path:           aws.String(path),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	})
+	for p.HasMorePages() {
+		res, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parameters by path %s: %w", path, err)
+		}
+		for _, param := range res.Parameters {
+			param := param
+			name := aws.ToString(param.Name)
+			key := strings.TrimPrefix(strings.TrimPrefix(name, path), "/")
+			// prefer the cached value to return the same value in the run
+			if out, ok := s.cache.LoadOrStore(name, &ssm.GetParameterOutput{Parameter: &param}); ok {
+				param = *out.(*ssm.GetParameterOutput).Parameter
+			}
+			values[key] = aws.ToString(param.Value)
+			s.recordParameter(&param)
+		}
+	}
+	s.mu.Lock()
+	s.paths[path] = values
+	s.mu.Unlock()
+	return values, nil
+}
+
+// recordParameter records the value of the parameter when it is a secret.
+func (s *secretLookup) recordParameter(p *ssmtypes.Parameter) {
+	if p.Type != ssmtypes.ParameterTypeSecureString && !strings.HasPrefix(aws.ToString(p.Name), secretsManagerReferencePrefix) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addSecret(aws.ToString(p.Value))
+}
+
+// lookupSecret returns the value of the secret in Secrets Manager.
+// When the key is specified, the secret value is parsed as JSON and the value of the key is returned.
+func (s *secretLookup) lookupSecret(ctx context.Context, secretID string, key ...string) (string, error) {
+	if len(key) > 1 {
+		return "", fmt.Errorf("secretsmanager template function accepts at most 2 parameters, but got %d", len(key)+1)
+	}
+	p, err := s.getParameter(ctx, secretsManagerReferencePrefix+secretID)
+	if err != nil {
+		return "", err
+	}
+	value := aws.ToString(p.Value)
+	if len(key) == 0 {
+		return value, nil
+	}
+	var kv map[string]any
+	if err := json.Unmarshal([]byte(value), &kv); err != nil {
+		return "", fmt.Errorf("failed to parse secret %s as JSON: %w", secretID, err)
+	}
+	v, ok := kv[key[0]]
+	if !ok {
+		return "", fmt.Errorf("key %s is not found in secret %s", key[0], secretID)
+	}
+	var extracted string
+	if sv, ok := v.(string); ok {
+		extracted = sv
+	} else {
+		b, _ := json.Marshal(v)
+		extracted = string(b)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addSecret(extracted)
+	return extracted, nil
+}
+
+// addSecret records the resolved secret value. The caller must hold the lock.
+func (s *secretLookup) addSecret(v string) {
+	if len(v) >= redactMinLength {
+		s.secrets[v] = struct{}{}
+	}
+}
+
+// secretValues returns the recorded values, longer values first because a value may contain another one.
+func (s *secretLookup) secretValues() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secrets := make([]string, 0, len(s.secrets))
+	for sv := range s.secrets {
+		secrets = append(secrets, sv)
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+	return secrets
+}
+
+// redactPair masks the values in local which contain the resolved values,
+// and the values at the same paths in remote. So the values of the remote
+// (e.g. secrets before rotation) are masked even if they are not resolved in this run.
+// When the masked values differ, the local value is marked as changed.
+// The resolved values in the other strings are replaced with the mask.
+func (s *secretLookup) redactPair(remote, local any) (any, any) {
+	return redactPairValue(remote, local, s.secretValues())
+}
+
+func redactPairValue(remote, local any, secrets []string) (any, any) {
+	switch l := local.(type) {
+	case string:
+		if !containsAny(l, secrets) {
+			break
+		}
+		if remote == nil {
+			return nil, redactedValue
+		}
+		if r, ok := remote.(string); ok && r == l {
+			return redactedValue, redactedValue
+		}
+		return redactedValue, redactedValue + " (changed)"
+	case map[string]any:
+		r, ok := remote.(map[string]any)
+		if !ok && remote != nil {
+			break
+		}
+		rm := make(map[string]any, len(r))
+		lm := make(map[string]any, len(l))
+		for k, v := range l {
+			if rv, ok := r[k]; ok {
+				rm[k], lm[k] = redactPairValue(rv, v, secrets)
+			} else {
+				_, lm[k] = redactPairValue(nil, v, secrets)
+			}
+		}
+		for k, v := range r {
+			if _, ok := l[k]; !ok {
+				rm[k] = redactValue(v, secrets)
+			}
+		}
+		if remote == nil {
+			return nil, lm
+		}
+		return rm, lm
+	case []any:
+		r, ok := remote.([]any)
+		if !ok && remote != nil {
+			break
+		}
+		ra := make([]any, len(r))
+		la := make([]any, len(l))
+		for i := range l {
+			if i < len(r) {
+				ra[i], la[i] = redactPairValue(r[i], l[i], secrets)
+			} else {
+				_, la[i] = redactPairValue(nil, l[i], secrets)
+			}
+		}
+		for i := len(l); i < len(r); i++ {
+			ra[i] = redactValue(r[i], secrets)
+		}
+		if remote == nil {
+			return nil, la
+		}
+		return ra, la
+	}
+	if remote == nil {
+		return nil, redactValue(local, secrets)
+	}
+	return redactValue(remote, secrets), redactValue(local, secrets)
+}
+
+func containsAny(s string, secrets []string) bool {
+	for _, sv := range secrets {
+		if strings.Contains(s, sv) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactValue(v any, secrets []string) any {
+	switch vv := v.(type) {
+	case string:
+		for _, sv := range secrets {
+			vv = strings.ReplaceAll(vv, sv, redactedValue)
+		}
+		return vv
+	case map[string]any:
+		m := make(map[string]any, len(vv))
+		for k, x := range vv {
+			m[k] = redactValue(x, secrets)
+		}
+		return m
+	case []any:
+		a := make([]any, len(vv))
+		for i, x := range vv {
+			a[i] = redactValue(x, secrets)
+		}
+		return a
+	default:
+		return v
+	}
+}
+
+// redactJSON masks the resolved values in the JSON.
+func (app *App) redactJSON(b []byte) ([]byte, error) {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	_, v = app.secrets.redactPair(nil, v)
+	if b, err := json.MarshalIndent(v, "", "  "); err != nil {
+		return nil, err
+	} else {
+		return append(b, '\n'), nil
+	}
+}
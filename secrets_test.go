@@ -0,0 +1,212 @@
+package lambroll
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	ssmlookup "github.com/fujiwara/ssm-lookup/ssm"
+	"github.com/google/go-cmp/cmp"
+)
+
+type fakeSSM struct {
+	params map[string]ssmtypes.Parameter
+	calls  int
+}
+
+func (f *fakeSSM) GetParameter(ctx context.Context, in *ssm.GetParameterInput, _ ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	f.calls++
+	p, ok := f.params[aws.ToString(in.Name)]
+	if !ok {
+		return nil, fmt.Errorf("parameter %s not found", aws.ToString(in.Name))
+	}
+	return &ssm.GetParameterOutput{Parameter: &p}, nil
+}
+
+func (f *fakeSSM) GetParametersByPath(ctx context.Context, in *ssm.GetParametersByPathInput, _ ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	f.calls++
+	out := &ssm.GetParametersByPathOutput{}
+	for name, p := range f.params {
+		if strings.HasPrefix(name, aws.ToString(in.Path)+"/") {
+			out.Parameters = append(out.Parameters, p)
+		}
+	}
+	return out, nil
+}
+
+func newFakeSSM() *fakeSSM {
+	params := map[string]ssmtypes.Parameter{}
+	for _, p := range []struct {
+		name, value string
+		typ         ssmtypes.ParameterType
+	}{
+		{"/app/prod/DB_HOST", "db.example.com", ssmtypes.ParameterTypeString},
+		{"/app/prod/DB_PASSWORD", "s3cr3t-password", ssmtypes.ParameterTypeSecureString},
+		{"/app/prod/subnets", "subnet-a,subnet-b", ssmtypes.ParameterTypeStringList},
+		{secretsManagerReferencePrefix + "app/api", `{"api_key":"my-api-key-0123","port":8080}`, ssmtypes.ParameterTypeSecureString},
+	} {
+		params[p.name] = ssmtypes.Parameter{Name: aws.String(p.name), Value: aws.String(p.value), Type: p.typ}
+	}
+	return &fakeSSM{params: params}
+}
+
+// newFakeSSMServer returns a server of GetParameter API for ssm-lookup.
+func newFakeSSMServer(t *testing.T, params map[string]ssmtypes.Parameter, calls *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		if target := r.Header.Get("X-Amz-Target"); target != "AmazonSSM.GetParameter" {
+			t.Errorf("unexpected target %s", target)
+		}
+		var in struct{ Name string }
+		json.NewDecoder(r.Body).Decode(&in)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		p, ok := params[in.Name]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ParameterNotFound"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"Parameter": map[string]string{"Name": aws.ToString(p.Name), "Type": string(p.Type), "Value": aws.ToString(p.Value)},
+		})
+	}))
+}
+
+func TestSecretLookupFuncs(t *testing.T) {
+	f := newFakeSSM()
+	s := newSecretLookup(f)
+	var httpCalls int
+	ts := newFakeSSMServer(t, map[string]ssmtypes.Parameter{
+		"/app/other": {Name: aws.String("/app/other"), Type: ssmtypes.ParameterTypeString, Value: aws.String("other-value")},
+	}, &httpCalls)
+	defer ts.Close()
+	lookup := ssmlookup.New(aws.Config{
+		Region:       "us-east-1",
+		Credentials:  aws.AnonymousCredentials{},
+		BaseEndpoint: aws.String(ts.URL),
+	}, s.cache)
+
+	ctx := context.Background()
+	tmpl := template.Must(template.New("test").Funcs(s.FuncMap(ctx, lookup)).Parse(
+		`{{ secretsmanager "app/api" "api_key" }} {{ secretsmanager "app/api" "port" }} ` +
+			`{{ with ssm_by_path "/app/prod" }}{{ .DB_PASSWORD }}{{ end }} ` +
+			`{{ index (ssm_by_path "/app/prod") "DB_HOST" }} ` +
+			`{{ ssm "/app/prod/DB_PASSWORD" }} {{ ssm "/app/prod/subnets" 1 }} {{ ssm "/app/other" }}`,
+	))
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := "my-api-key-0123 8080 s3cr3t-password db.example.com s3cr3t-password subnet-b other-value"
+	if b.String() != expected {
+		t.Errorf("unexpected output %q", b.String())
+	}
+	// app/api and the path are looked up once, and the ssm function uses the same cache
+	if f.calls != 2 || httpCalls != 1 {
+		t.Errorf("unexpected API calls %d %d", f.calls, httpCalls)
+	}
+	if p, err := s.getParameter(ctx, "/app/other"); err != nil || aws.ToString(p.Value) != "other-value" || f.calls != 2 {
+		t.Errorf("the parameter resolved by the ssm function must be cached: %v %v", p, err)
+	}
+
+	_, redacted := s.redactPair(nil, map[string]any{
+		"Variables": map[string]any{
+			"DB_PASSWORD": "s3cr3t-password",
+			"API":         "key=my-api-key-0123",
+			"PORT":        "8080",
+			"DB_HOST":     "db.example.com",
+			"OTHER":       "other-value",
+		},
+		"List": []any{"s3cr3t-password", 1.0},
+	})
+	// only SecureString and secrets are redacted. short values are not redacted
+	expectedRedacted := map[string]any{
+		"Variables": map[string]any{
+			"DB_PASSWORD": redactedValue,
+			"API":         redactedValue,
+			"PORT":        "8080",
+			"DB_HOST":     "db.example.com",
+			"OTHER":       "other-value",
+		},
+		"List": []any{redactedValue, 1.0},
+	}
+	if diff := cmp.Diff(expectedRedacted, redacted); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestRedactRotatedSecret(t *testing.T) {
+	s := newSecretLookup(newFakeSSM())
+	ctx := context.Background()
+	if _, err := s.lookupSecret(ctx, "app/api", "api_key"); err != nil {
+		t.Fatal(err)
+	}
+	// the remote has the secret value before rotation, which is not resolved in this run
+	remote := map[string]any{
+		"Environment": map[string]any{
+			"Variables": map[string]any{
+				"API_KEY": "old-api-key-9999",
+				"STAGE":   "prod",
+				"REMOVED": "my-api-key-0123",
+			},
+		},
+	}
+	local := map[string]any{
+		"Environment": map[string]any{
+			"Variables": map[string]any{
+				"API_KEY": "my-api-key-0123",
+				"STAGE":   "prod",
+			},
+		},
+	}
+	r, l := s.redactPair(remote, local)
+	expectedRemote := map[string]any{
+		"Environment": map[string]any{
+			"Variables": map[string]any{
+				"API_KEY": redactedValue,
+				"STAGE":   "prod",
+				"REMOVED": redactedValue,
+			},
+		},
+	}
+	expectedLocal := map[string]any{
+		"Environment": map[string]any{
+			"Variables": map[string]any{
+				"API_KEY": redactedValue + " (changed)",
+				"STAGE":   "prod",
+			},
+		},
+	}
+	if diff := cmp.Diff(expectedRemote, r); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(expectedLocal, l); diff != "" {
+		t.Error(diff)
+	}
+
+	// not changed
+	r, l = s.redactPair(local, local)
+	if diff := cmp.Diff(r, l); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestSecretLookupErrors(t *testing.T) {
+	s := newSecretLookup(newFakeSSM())
+	ctx := context.Background()
+	if _, err := s.lookupSecret(ctx, "app/api", "missing"); err == nil {
+		t.Error("expected error for a missing key")
+	}
+	if _, err := s.lookupSecret(ctx, "app/missing"); err == nil {
+		t.Error("expected error for a missing secret")
+	}
+}
@@ -0,0 +1,288 @@
+package lambroll
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/olekukonko/tablewriter"
+)
+
+// StatsOption represents options for Stats()
+type StatsOption struct {
+	Since     string  `help:"From what time to begin aggregating logs" default:"1h"`
+	Until     string  `help:"To what time to end aggregating logs (default: now)" default:""`
+	Qualifier *string `help:"aggregate only logs of the version or alias"`
+	Output    string  `help:"output format" default:"table" enum:"table,json"`
+}
+
+// StatsOutput represents a summary of invocations aggregated from REPORT log records.
+type StatsOutput struct {
+	FunctionName string    `json:"FunctionName"`
+	LogGroup     string    `json:"LogGroup"`
+	Since        time.Time `json:"Since"`
+	Until        time.Time `json:"Until"`
+
+	Invocations int `json:"Invocations"`
+	Errors      int `json:"Errors"`
+	ColdStarts  int `json:"ColdStarts"`
+
+	DurationP50         float64 `json:"DurationP50"`
+	DurationP90         float64 `json:"DurationP90"`
+	DurationP99         float64 `json:"DurationP99"`
+	DurationMax         float64 `json:"DurationMax"`
+	BilledDurationTotal float64 `json:"BilledDurationTotal"`
+	InitDurationP50     float64 `json:"InitDurationP50"`
+	InitDurationMax     float64 `json:"InitDurationMax"`
+	MemorySize          int     `json:"MemorySize"`
+	MaxMemoryUsed       int     `json:"MaxMemoryUsed"`
+}
+
+func (o *StatsOutput) String() string {
+	ms := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', 2, 64) + " ms"
+	}
+	buf := new(strings.Builder)
+	w := tablewriter.NewWriter(buf)
+	w.Append([]string{"FunctionName", o.FunctionName})
+	w.Append([]string{"LogGroup", o.LogGroup})
+	w.Append([]string{"Period", o.Since.Local().Format(time.RFC3339) + " - " + o.Until.Local().Format(time.RFC3339)})
+	w.Append([]string{"Invocations", strconv.Itoa(o.Invocations)})
+	w.Append([]string{"Errors", strconv.Itoa(o.Errors)})
+	w.Append([]string{"ColdStarts", strconv.Itoa(o.ColdStarts)})
+	w.Append([]string{"Duration p50", ms(o.DurationP50)})
+	w.Append([]string{"Duration p90", ms(o.DurationP90)})
+	w.Append([]string{"Duration p99", ms(o.DurationP99)})
+	w.Append([]string{"Duration max", ms(o.DurationMax)})
+	w.Append([]string{"Billed Duration total", ms(o.BilledDurationTotal)})
+	if o.ColdStarts > 0 {
+		w.Append([]string{"Init Duration p50", ms(o.InitDurationP50)})
+		w.Append([]string{"Init Duration max", ms(o.InitDurationMax)})
+	}
+	mem := fmt.Sprintf("%d MB", o.MaxMemoryUsed)
+	if o.MemorySize > 0 {
+		mem += fmt.Sprintf(" / %d MB (%.1f%%)", o.MemorySize, float64(o.MaxMemoryUsed)/float64(o.MemorySize)*100)
+	}
+	w.Append([]string{"Max Memory Used", mem})
+	w.Render()
+	return buf.String()
+}
+
+// invocationReport represents a REPORT log record of an invocation.
+type invocationReport struct {
+	RequestID      string
+	Duration       float64
+	BilledDuration float64
+	MemorySize     int
+	MaxMemoryUsed  int
+	InitDuration   float64
+	Status         string
+}
+
+var reportFieldRegexp = regexp.MustCompile(`(RequestId|Duration|Billed Duration|Memory Size|Max Memory Used|Init Duration|Status): ([^\t]+?)(?: ms| MB)?(?:\t|$)`)
+
+// parseInvocationReport parses a REPORT log record in text or JSON (platform.report) format.
+func parseInvocationReport(msg string) (*invocationReport, bool) {
+	msg = strings.TrimSpace(msg)
+	if record, ok := parseLogRecord(msg); ok {
+		if record["type"] != "platform.report" {
+			return nil, false
+		}
+		rec, _ := record["record"].(map[string]any)
+		metrics, _ := rec["metrics"].(map[string]any)
+		num := func(key string) float64 {
+			f, _ := metrics[key].(float64)
+			return f
+		}
+		r := &invocationReport{
+			Duration:       num("durationMs"),
+			BilledDuration: num("billedDurationMs"),
+			MemorySize:     int(num("memorySizeMB")),
+			MaxMemoryUsed:  int(num("maxMemoryUsedMB")),
+			InitDuration:   num("initDurationMs"),
+		}
+		r.RequestID, _ = rec["requestId"].(string)
+		r.Status, _ = rec["status"].(string)
+		return r, true
+	}
+	if !strings.HasPrefix(msg, "REPORT ") {
+		return nil, false
+	}
+	r := &invocationReport{}
+	for _, m := range reportFieldRegexp.FindAllStringSubmatch(msg, -1) {
+		switch m[1] {
+		case "RequestId":
+			r.RequestID = m[2]
+		case "Duration":
+			r.Duration, _ = strconv.ParseFloat(m[2], 64)
+		case "Billed Duration":
+			r.BilledDuration, _ = strconv.ParseFloat(m[2], 64)
+		case "Memory Size":
+			r.MemorySize, _ = strconv.Atoi(m[2])
+		case "Max Memory Used":
+			r.MaxMemoryUsed, _ = strconv.Atoi(m[2])
+		case "Init Duration":
+			r.InitDuration, _ = strconv.ParseFloat(m[2], 64)
+		case "Status":
+			r.Status = m[2]
+		}
+	}
+	return r, true
+}
+
+// parseErrorRequestID returns the request ID of an error log record in text or JSON format.
+// Text format records are "timestamp\trequestId\tERROR\tmessage" (Node.js) or "[ERROR]\ttimestamp\trequestId\tmessage" (Python).
+func parseErrorRequestID(msg string) (string, bool) {
+	msg = strings.TrimSpace(msg)
+	if record, ok := parseLogRecord(msg); ok {
+		level, _ := record["level"].(string)
+		if !isErrorLevel(level) {
+			return "", false
+		}
+		id := logRecordRequestID(record)
+		return id, id != ""
+	}
+	fields := strings.SplitN(msg, "\t", 4)
+	if len(fields) < 3 {
+		return "", false
+	}
+	switch {
+	case isErrorLevel(strings.Trim(fields[0], "[]")):
+		return fields[2], fields[2] != ""
+	case isErrorLevel(fields[2]):
+		return fields[1], fields[1] != ""
+	}
+	return "", false
+}
+
+func isErrorLevel(level string) bool {
+	return level == "ERROR" || level == "FATAL"
+}
+
+// percentile returns the p-th percentile of sorted values by nearest-rank method.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// aggregateInvocationReports aggregates the reports into out.
+// An invocation is counted as an error when the status of the report is not success
+// or the request has error log records (errorRequests).
+func aggregateInvocationReports(out *StatsOutput, reports []*invocationReport, errorRequests map[string]bool) {
+	var durations, inits []float64
+	for _, r := range reports {
+		out.Invocations++
+		if r.Status != "" && r.Status != "success" || errorRequests[r.RequestID] {
+			out.Errors++
+		}
+		durations = append(durations, r.Duration)
+		out.BilledDurationTotal += r.BilledDuration
+		if r.InitDuration > 0 {
+			out.ColdStarts++
+			inits = append(inits, r.InitDuration)
+		}
+		if r.MaxMemoryUsed > out.MaxMemoryUsed {
+			out.MaxMemoryUsed = r.MaxMemoryUsed
+		}
+		if out.MemorySize == 0 {
+			out.MemorySize = r.MemorySize
+		}
+	}
+	sort.Float64s(durations)
+	sort.Float64s(inits)
+	out.DurationP50 = percentile(durations, 50)
+	out.DurationP90 = percentile(durations, 90)
+	out.DurationP99 = percentile(durations, 99)
+	out.DurationMax = percentile(durations, 100)
+	out.InitDurationP50 = percentile(inits, 50)
+	out.InitDurationMax = percentile(inits, 100)
+}
+
+// Stats shows a summary of invocations aggregated from REPORT log records
+func (app *App) Stats(ctx context.Context, opt *StatsOption) error {
+	fn, err := app.loadFunction(app.functionFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to load function: %w", err)
+	}
+	now := time.Now()
+	since, err := parseSince(opt.Since, now)
+	if err != nil {
+		return err
+	}
+	until := now
+	if opt.Until != "" {
+		if until, err = parseSince(opt.Until, now); err != nil {
+			return err
+		}
+	}
+	var versions []string
+	if opt.Qualifier != nil && *opt.Qualifier != "" {
+		if versions, err = app.resolveQualifierVersions(ctx, *fn.FunctionName, *opt.Qualifier); err != nil {
+			return err
+		}
+	}
+
+	out := &StatsOutput{
+		FunctionName: *fn.FunctionName,
+		LogGroup:     resolveLogGroup(fn),
+		Since:        since,
+		Until:        until,
+		MemorySize:   int(aws.ToInt32(fn.MemorySize)),
+	}
+	filterPattern := `?"REPORT RequestId" ?ERROR ?FATAL`
+	if fn.LoggingConfig != nil && fn.LoggingConfig.LogFormat == types.LogFormatJson {
+		filterPattern = `{ $.type = "platform.report" || $.level = "ERROR" || $.level = "FATAL" }`
+	}
+	log.Printf("[debug] aggregating REPORT and error records of %s from %s to %s", out.LogGroup, since.Format(time.RFC3339), until.Format(time.RFC3339))
+
+	var reports []*invocationReport
+	errorRequests := make(map[string]bool)
+	p := cloudwatchlogs.NewFilterLogEventsPaginator(cloudwatchlogs.NewFromConfig(app.awsConfig), &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:  aws.String(out.LogGroup),
+		StartTime:     aws.Int64(since.UnixMilli()),
+		EndTime:       aws.Int64(until.UnixMilli()),
+		FilterPattern: aws.String(filterPattern),
+	})
+	for p.HasMorePages() {
+		res, err := p.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to filter log events of %s: %w", out.LogGroup, err)
+		}
+		for _, ev := range res.Events {
+			if !matchLogStreamVersion(aws.ToString(ev.LogStreamName), versions) {
+				continue
+			}
+			msg := aws.ToString(ev.Message)
+			if r, ok := parseInvocationReport(msg); ok {
+				reports = append(reports, r)
+			} else if id, ok := parseErrorRequestID(msg); ok {
+				errorRequests[id] = true
+			}
+		}
+	}
+	aggregateInvocationReports(out, reports, errorRequests)
+
+	switch opt.Output {
+	case "table":
+		fmt.Print(out.String())
+	case "json":
+		b, _ := marshalJSON(out)
+		fmt.Print(string(b))
+	}
+	return nil
+}
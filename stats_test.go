@@ -0,0 +1,119 @@
+package lambroll
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var invocationReportTestCases = []struct {
+	msg      string
+	expected *invocationReport
+}{
+	{
+		msg: "REPORT RequestId: 60140e16-018e-41b1-bb46-3f021d4960c0\tDuration: 561.77 ms\tBilled Duration: 600 ms\tMemory Size: 128 MB\tMax Memory Used: 50 MB\tInit Duration: 150.12 ms\t\n",
+		expected: &invocationReport{
+			RequestID:      "60140e16-018e-41b1-bb46-3f021d4960c0",
+			Duration:       561.77,
+			BilledDuration: 600,
+			MemorySize:     128,
+			MaxMemoryUsed:  50,
+			InitDuration:   150.12,
+		},
+	},
+	{
+		msg: "REPORT RequestId: dcc584f5-ceaf-4109-b405-8e59ca7ae92f\tDuration: 3000.00 ms\tBilled Duration: 3000 ms\tMemory Size: 128 MB\tMax Memory Used: 64 MB\tStatus: timeout",
+		expected: &invocationReport{
+			RequestID:      "dcc584f5-ceaf-4109-b405-8e59ca7ae92f",
+			Duration:       3000,
+			BilledDuration: 3000,
+			MemorySize:     128,
+			MaxMemoryUsed:  64,
+			Status:         "timeout",
+		},
+	},
+	{
+		msg: `{"time":"2024-03-14T12:00:00.000Z","type":"platform.report","record":{"requestId":"aaa","metrics":{"durationMs":12.5,"billedDurationMs":13,"memorySizeMB":256,"maxMemoryUsedMB":80},"status":"success"}}`,
+		expected: &invocationReport{
+			RequestID:      "aaa",
+			Duration:       12.5,
+			BilledDuration: 13,
+			MemorySize:     256,
+			MaxMemoryUsed:  80,
+			Status:         "success",
+		},
+	},
+	{
+		msg:      "START RequestId: aaa Version: 1",
+		expected: nil,
+	},
+}
+
+func TestParseInvocationReport(t *testing.T) {
+	for i, c := range invocationReportTestCases {
+		r, ok := parseInvocationReport(c.msg)
+		if ok != (c.expected != nil) {
+			t.Errorf("case %d: unexpected ok %v", i, ok)
+			continue
+		}
+		if d := cmp.Diff(c.expected, r); d != "" {
+			t.Errorf("case %d: report mismatch: diff:%s", i, d)
+		}
+	}
+}
+
+func TestAggregateInvocationReports(t *testing.T) {
+	var reports []*invocationReport
+	for i := 1; i <= 100; i++ {
+		r := &invocationReport{Duration: float64(i), BilledDuration: float64(i), MemorySize: 128, MaxMemoryUsed: 40 + i%10}
+		if i%50 == 0 {
+			r.InitDuration = 100
+		}
+		if i == 100 {
+			r.Status = "error"
+		}
+		r.RequestID = strconv.Itoa(i)
+		reports = append(reports, r)
+	}
+	out := &StatsOutput{}
+	// the handled error of 10 and the error record of 100 which is also failed by the status
+	aggregateInvocationReports(out, reports, map[string]bool{"10": true, "100": true})
+	expected := &StatsOutput{
+		Invocations:         100,
+		Errors:              2,
+		ColdStarts:          2,
+		DurationP50:         50,
+		DurationP90:         90,
+		DurationP99:         99,
+		DurationMax:         100,
+		BilledDurationTotal: 5050,
+		InitDurationP50:     100,
+		InitDurationMax:     100,
+		MemorySize:          128,
+		MaxMemoryUsed:       49,
+	}
+	if d := cmp.Diff(expected, out); d != "" {
+		t.Errorf("stats mismatch: diff:%s", d)
+	}
+}
+
+func TestParseErrorRequestID(t *testing.T) {
+	cases := []struct {
+		msg      string
+		expected string
+	}{
+		{msg: "2024-03-14T03:17:26.123Z\taaa-111\tERROR\tInvoke Error \t{\"errorType\":\"Error\"}", expected: "aaa-111"},
+		{msg: "[ERROR]\t2024-03-14T03:17:26.123Z\tbbb-222\tsomething wrong", expected: "bbb-222"},
+		{msg: `{"timestamp":"2024-03-14T03:17:26.123Z","level":"ERROR","requestId":"ccc-333","message":"failed"}`, expected: "ccc-333"},
+		{msg: "2024-03-14T03:17:26.123Z\taaa-111\tINFO\tERROR is not the level"},
+		{msg: `{"timestamp":"2024-03-14T03:17:26.123Z","level":"INFO","requestId":"ccc-333","message":"ERROR"}`},
+		{msg: "[ERROR] Runtime.ImportModuleError: Unable to import module"},
+	}
+	for i, c := range cases {
+		id, ok := parseErrorRequestID(c.msg)
+		if ok != (c.expected != "") || id != c.expected {
+			t.Errorf("case %d: unexpected request ID %q %v", i, id, ok)
+		}
+	}
+}
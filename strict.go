@@ -0,0 +1,532 @@
+package lambroll
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"github.com/google/go-jsonnet/ast"
+)
+
+// definitionError represents an error at the position in the definition file.
+type definitionError struct {
+	File    string
+	Line    int
+	Column  int
+	Path    jsonPath
+	Message string
+}
+
+func (e *definitionError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s at %s", e.File, e.Line, e.Column, e.Message, e.Path)
+	}
+	return fmt.Sprintf("%s: %s at %s", e.File, e.Message, e.Path)
+}
+
+// jsonPath represents a path to the value in JSON. The elements are string keys or int indexes.
+type jsonPath []any
+
+func (p jsonPath) String() string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, e := range p {
+		switch v := e.(type) {
+		case int:
+			fmt.Fprintf(&b, "[%d]", v)
+		case string:
+			if envKeyRegexp.MatchString(v) {
+				b.WriteString("." + v)
+			} else {
+				fmt.Fprintf(&b, "[%s]", strconv.Quote(v))
+			}
+		}
+	}
+	return b.String()
+}
+
+func (p jsonPath) append(e any) jsonPath {
+	np := make(jsonPath, len(p), len(p)+1)
+	copy(np, p)
+	return append(np, e)
+}
+
+// jsonNode represents a JSON value with the offset in the source.
+type jsonNode struct {
+	offset  int64
+	value   any // string, json.Number, bool, nil, []*jsonNode or []*jsonMember
+	isArray bool
+}
+
+type jsonMember struct {
+	key    string
+	offset int64
+	value  *jsonNode
+}
+
+// parseJSONNodes parses src into jsonNode tree which holds the offset of each value.
+func parseJSONNodes(src []byte) (*jsonNode, error) {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+	return parseJSONNode(dec, src)
+}
+
+func parseJSONNode(dec *json.Decoder, src []byte) (*jsonNode, error) {
+	offset := skipJSONSeparators(src, dec.InputOffset())
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	node := &jsonNode{offset: offset}
+	switch tok {
+	case json.Delim('{'):
+		members := []*jsonMember{}
+		for dec.More() {
+			keyOffset := skipJSONSeparators(src, dec.InputOffset())
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := parseJSONNode(dec, src)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, &jsonMember{key: key.(string), offset: keyOffset, value: value})
+		}
+		if _, err := dec.Token(); err != nil { // }
+			return nil, err
+		}
+		node.value = members
+	case json.Delim('['):
+		elements := []*jsonNode{}
+		for dec.More() {
+			e, err := parseJSONNode(dec, src)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, e)
+		}
+		if _, err := dec.Token(); err != nil { // ]
+			return nil, err
+		}
+		node.value = elements
+		node.isArray = true
+	default:
+		node.value = tok
+	}
+	return node, nil
+}
+
+func skipJSONSeparators(src []byte, offset int64) int64 {
+	for offset < int64(len(src)) {
+		switch src[offset] {
+		case ' ', '\t', '\r', '\n', ':', ',':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+type strictProblem struct {
+	offset  int64
+	path    jsonPath
+	message string
+	// atKey reports the problem is at the key of the member, not the value
+	atKey bool
+}
+
+// checkJSONNode checks the node can be decoded into the type t without unknown fields and type mismatches.
+func checkJSONNode(node *jsonNode, t reflect.Type, path jsonPath) []strictProblem {
+	if node.value == nil {
+		return nil // null is acceptable for any types
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return nil // cannot check by the type
+	}
+	mismatch := func(expected string) []strictProblem {
+		return []strictProblem{{
+			offset:  node.offset,
+			path:    path,
+			message: fmt.Sprintf("type mismatch: expected %s, got %s", expected, jsonNodeType(node)),
+		}}
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return nil
+	case reflect.Struct:
+		members, ok := node.value.([]*jsonMember)
+		if !ok {
+			return mismatch("object")
+		}
+		var problems []strictProblem
+		fields := jsonFields(t)
+		for _, m := range members {
+			f, ok := lookupJSONField(fields, m.key)
+			if !ok {
+				problems = append(problems, strictProblem{
+					offset:  m.offset,
+					path:    path.append(m.key),
+					message: fmt.Sprintf("unknown field %q", m.key),
+					atKey:   true,
+				})
+				continue
+			}
+			problems = append(problems, checkJSONNode(m.value, f.Type, path.append(m.key))...)
+		}
+		return problems
+	case reflect.Map:
+		members, ok := node.value.([]*jsonMember)
+		if !ok {
+			return mismatch("object")
+		}
+		var problems []strictProblem
+		for _, m := range members {
+			problems = append(problems, checkJSONNode(m.value, t.Elem(), path.append(m.key))...)
+		}
+		return problems
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			if _, ok := node.value.(string); !ok {
+				return mismatch("base64 string")
+			}
+			return nil
+		}
+		elements, ok := node.value.([]*jsonNode)
+		if !ok || !node.isArray {
+			return mismatch("array")
+		}
+		var problems []strictProblem
+		for i, e := range elements {
+			problems = append(problems, checkJSONNode(e, t.Elem(), path.append(i))...)
+		}
+		return problems
+	case reflect.String:
+		if _, ok := node.value.(string); !ok {
+			return mismatch("string")
+		}
+	case reflect.Bool:
+		if _, ok := node.value.(bool); !ok {
+			return mismatch("boolean")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := node.value.(json.Number)
+		if !ok {
+			return mismatch("integer")
+		}
+		if _, err := strconv.ParseInt(string(n), 10, 64); err != nil {
+			return mismatch("integer")
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := node.value.(json.Number); !ok {
+			return mismatch("number")
+		}
+	}
+	return nil
+}
+
+func jsonNodeType(node *jsonNode) string {
+	switch node.value.(type) {
+	case []*jsonMember:
+		return "object"
+	case []*jsonNode:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+// jsonFields returns the fields of the struct which are decoded by encoding/json.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range jsonFields(ft) {
+					if _, exists := fields[k]; !exists {
+						fields[k] = v
+					}
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+// lookupJSONField finds the field by the key as same as encoding/json (case-insensitive).
+func lookupJSONField(fields map[string]reflect.StructField, key string) (reflect.StructField, bool) {
+	if f, ok := fields[key]; ok {
+		return f, true
+	}
+	for name, f := range fields {
+		if strings.EqualFold(name, key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// strictCheckDefinition checks the rendered definition src of the files against the type of v.
+// files are the definition file and the overlay file merged on it (if any).
+// It returns errors which have the JSON path and the position in the file which contains the field.
+// The positions are looked up in the source files, because the rendered JSON differs from them
+// by the templates, Jsonnet evaluation and overlays.
+// When the position is not found in the source, the error has the JSON path only.
+func strictCheckDefinition(src []byte, v any, files ...string) []*definitionError {
+	root, err := parseJSONNodes(src)
+	if err != nil {
+		return []*definitionError{{File: files[0], Path: jsonPath{}, Message: err.Error()}}
+	}
+	problems := checkJSONNode(root, reflect.TypeOf(v), jsonPath{})
+	if len(problems) == 0 {
+		return nil
+	}
+
+	sources := make([]*definitionSource, 0, len(files))
+	for _, f := range files {
+		sources = append(sources, loadDefinitionSource(f, src))
+	}
+	errs := make([]*definitionError, 0, len(problems))
+	for _, p := range problems {
+		e := &definitionError{File: files[0], Path: p.path, Message: p.message}
+		// the file which has the deepest part of the path contains the field.
+		// the later file overrides the former, so it is preferred when the depths are the same.
+		depth := -1
+		for _, ds := range sources {
+			line, column, d := ds.location(p)
+			if d < 0 || (d == 0 && len(p.path) > 0) || d < depth {
+				continue // not found in the source
+			}
+			depth = d
+			e.File, e.Line, e.Column = ds.file, line, column
+		}
+		errs = append(errs, e)
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].File != errs[j].File {
+			return errs[i].File < errs[j].File
+		}
+		if errs[i].Line != errs[j].Line {
+			return errs[i].Line < errs[j].Line
+		}
+		return errs[i].Column < errs[j].Column
+	})
+	return errs
+}
+
+// definitionSource represents the source of a definition file to look up the positions.
+type definitionSource struct {
+	file    string
+	src     []byte
+	json    *jsonNode
+	jsonnet ast.Node
+}
+
+// loadDefinitionSource loads the source of the file.
+// When the file cannot be read, the rendered src is used as the source.
+func loadDefinitionSource(file string, rendered []byte) *definitionSource {
+	ds := &definitionSource{file: file}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		b = rendered
+	}
+	ds.src = b
+	if filepath.Ext(file) == ".jsonnet" {
+		if err == nil {
+			ds.jsonnet, _ = jsonnet.SnippetToAST(file, string(b))
+		}
+		return ds
+	}
+	// JSON with templates outside of strings cannot be parsed
+	ds.json, _ = parseJSONNodes(b)
+	return ds
+}
+
+// location returns the position of the problem in the source and the depth of the path found.
+// The depth is -1 when the source cannot be parsed.
+func (ds *definitionSource) location(p strictProblem) (int, int, int) {
+	switch {
+	case ds.jsonnet != nil:
+		loc, depth := jsonnetLocation(ds.jsonnet, p.path)
+		if loc == nil {
+			return 0, 0, depth
+		}
+		return loc.Begin.Line, loc.Begin.Column, depth
+	case ds.json != nil:
+		offset, depth := jsonNodeLocation(ds.json, p.path, p.atKey)
+		line, column := lineColumn(ds.src, offset)
+		return line, column, depth
+	}
+	return 0, 0, -1
+}
+
+// jsonNodeLocation returns the offset of the node at the path and the depth of the path found.
+// When atKey is true, it returns the offset of the key of the last member.
+func jsonNodeLocation(node *jsonNode, path jsonPath, atKey bool) (int64, int) {
+	if len(path) == 0 {
+		return node.offset, 0
+	}
+	switch v := node.value.(type) {
+	case []*jsonMember:
+		key, ok := path[0].(string)
+		if !ok {
+			break
+		}
+		for _, m := range v {
+			if m.key != key {
+				continue
+			}
+			if len(path) == 1 && atKey {
+				return m.offset, 1
+			}
+			offset, depth := jsonNodeLocation(m.value, path[1:], atKey)
+			return offset, depth + 1
+		}
+	case []*jsonNode:
+		i, ok := path[0].(int)
+		if !ok || i >= len(v) {
+			break
+		}
+		offset, depth := jsonNodeLocation(v[i], path[1:], atKey)
+		return offset, depth + 1
+	}
+	return node.offset, 0
+}
+
+// lineColumn returns 1-origin line and column of the offset in src.
+func lineColumn(src []byte, offset int64) (int, int) {
+	if offset > int64(len(src)) {
+		offset = int64(len(src))
+	}
+	before := src[:offset]
+	line := bytes.Count(before, []byte{'\n'}) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// jsonnetLocation returns the location of the node at the path in Jsonnet AST and the depth of the path found.
+// Jsonnet is evaluated, so this follows only object literals, arrays, locals and object compositions.
+// When the path cannot be followed, it returns the location of the deepest node found.
+func jsonnetLocation(node ast.Node, path jsonPath) (*ast.LocationRange, int) {
+	if len(path) == 0 {
+		return node.Loc(), 0
+	}
+	switch n := node.(type) {
+	case *ast.Local:
+		return jsonnetLocation(n.Body, path)
+	case *ast.Parens:
+		return jsonnetLocation(n.Inner, path)
+	case *ast.Binary:
+		if n.Op == ast.BopPlus {
+			// the right side overrides the left side
+			rloc, rdepth := jsonnetLocation(n.Right, path)
+			lloc, ldepth := jsonnetLocation(n.Left, path)
+			if rdepth > 0 && rdepth >= ldepth {
+				return rloc, rdepth
+			}
+			if ldepth > 0 {
+				return lloc, ldepth
+			}
+		}
+	case *ast.DesugaredObject:
+		key, ok := path[0].(string)
+		if !ok {
+			break
+		}
+		for i, f := range n.Fields {
+			if name, ok := f.Name.(*ast.LiteralString); ok && name.Value == key {
+				if len(path) == 1 {
+					return &n.Fields[i].LocRange, 1
+				}
+				loc, depth := jsonnetLocation(f.Body, path[1:])
+				return loc, depth + 1
+			}
+		}
+	case *ast.Object:
+		key, ok := path[0].(string)
+		if !ok {
+			break
+		}
+		for i, f := range n.Fields {
+			var name string
+			if f.Id != nil {
+				name = string(*f.Id)
+			} else if s, ok := f.Expr1.(*ast.LiteralString); ok {
+				name = s.Value
+			}
+			if name == key {
+				if len(path) == 1 {
+					return &n.Fields[i].LocRange, 1
+				}
+				loc, depth := jsonnetLocation(f.Expr2, path[1:])
+				return loc, depth + 1
+			}
+		}
+	case *ast.Array:
+		i, ok := path[0].(int)
+		if !ok || i >= len(n.Elements) {
+			break
+		}
+		loc, depth := jsonnetLocation(n.Elements[i].Expr, path[1:])
+		return loc, depth + 1
+	}
+	return node.Loc(), 0
+}
+
+// strictUnmarshalJSON decodes src into v. It fails on unknown fields and type mismatches.
+// files are the definition file and the overlay file merged on it (if any).
+func strictUnmarshalJSON(src []byte, v any, files ...string) error {
+	if errs := strictCheckDefinition(src, v, files...); len(errs) > 0 {
+		joined := make([]error, 0, len(errs))
+		for _, e := range errs {
+			joined = append(joined, e)
+		}
+		return errors.Join(joined...)
+	}
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package lambroll
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const strictTestJSON = `{
+  "FunctionName": "hello",
+  "Enviroment": {
+    "Variables": {"FOO": "bar"}
+  },
+  "MemorySize": "128",
+  "Architectures": ["arm64", 1],
+  "Tags": {"my-tag": 1},
+  "VpcConfig": {"SubnetIds": ["a"], "SecurityGroupID": []}
+}
+`
+
+const strictTestJsonnet = `local name = 'hello';
+{
+  FunctionName: name,
+  Enviroment: {
+    Variables: { FOO: 'bar' },
+  },
+  MemorySize: '128',
+  Architectures: ['arm64', 1],
+  Tags: { 'my-tag': 1 },
+} + {
+  VpcConfig: { SubnetIds: ['a'], SecurityGroupID: [] },
+}
+`
+
+func TestStrictCheckDefinition(t *testing.T) {
+	dir := t.TempDir()
+	jsonnetPath := filepath.Join(dir, "function.jsonnet")
+	if err := os.WriteFile(jsonnetPath, []byte(strictTestJsonnet), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// rendered JSON of the Jsonnet
+	jsonnetRendered := []byte(`{"Architectures":["arm64",1],"Enviroment":{"Variables":{"FOO":"bar"}},"FunctionName":"hello","MemorySize":"128","Tags":{"my-tag":1},"VpcConfig":{"SecurityGroupID":[],"SubnetIds":["a"]}}`)
+
+	tests := []struct {
+		path     string
+		src      []byte
+		expected []string
+	}{
+		{
+			path: "function.json",
+			src:  []byte(strictTestJSON),
+			expected: []string{
+				`function.json:3:3: unknown field "Enviroment" at $.Enviroment`,
+				`function.json:6:17: type mismatch: expected integer, got string at $.MemorySize`,
+				`function.json:7:30: type mismatch: expected string, got number at $.Architectures[1]`,
+				`function.json:8:22: type mismatch: expected string, got number at $.Tags["my-tag"]`,
+				`function.json:9:37: unknown field "SecurityGroupID" at $.VpcConfig.SecurityGroupID`,
+			},
+		},
+		{
+			path: jsonnetPath,
+			src:  jsonnetRendered,
+			expected: []string{
+				jsonnetPath + `:4:3: unknown field "Enviroment" at $.Enviroment`,
+				jsonnetPath + `:7:3: type mismatch: expected integer, got string at $.MemorySize`,
+				jsonnetPath + `:8:28: type mismatch: expected string, got number at $.Architectures[1]`,
+				jsonnetPath + `:9:11: type mismatch: expected string, got number at $.Tags["my-tag"]`,
+				jsonnetPath + `:11:34: unknown field "SecurityGroupID" at $.VpcConfig.SecurityGroupID`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		var fn Function
+		errs := strictCheckDefinition(tt.src, &fn, tt.path)
+		got := make([]string, 0, len(errs))
+		for _, e := range errs {
+			got = append(got, e.Error())
+		}
+		if diff := cmp.Diff(tt.expected, got); diff != "" {
+			t.Errorf("%s: %s", tt.path, diff)
+		}
+	}
+}
+
+func TestStrictUnmarshalJSON(t *testing.T) {
+	var fn Function
+	src := []byte(`{"FunctionName":"hello","MemorySize":128,"Environment":{"Variables":{"FOO":"bar"}}}`)
+	if err := strictUnmarshalJSON(src, &fn, "function.json"); err != nil {
+		t.Fatal(err)
+	}
+	if *fn.FunctionName != "hello" || *fn.MemorySize != 128 || fn.Environment.Variables["FOO"] != "bar" {
+		t.Errorf("unexpected function %#v", fn)
+	}
+
+	var fu FunctionURL
+	src = []byte(`{"Config":{"AuthType":"NONE"},"Permissions":[{"Principal":"*","Sourcearn":"arn"}]}`)
+	if err := strictUnmarshalJSON(src, &fu, "function_url.json"); err != nil {
+		t.Errorf("unexpected error for embedded and case-insensitive fields: %s", err)
+	}
+	src = []byte(`{"Config":{"AuthType":"NONE"},"Permissions":[{"Principals":"*"}]}`)
+	if err := strictUnmarshalJSON(src, &fu, "function_url.json"); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestStrictCheckDefinitionSource(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	templated := write("templated.json", `{
+  "Description": "{{ must_env `+"`DESCRIPTION`"+` }}",
+  "MemorySize": "128"
+}
+`)
+	unparsable := write("unparsable.json", `{
+  "MemorySize": {{ env `+"`MEMORY_SIZE` `128`"+` }},
+  "Foo": "bar"
+}
+`)
+	base := write("function.json", `{
+  "FunctionName": "hello",
+  "MemorySize": "128",
+  "Timeout": 3
+}
+`)
+	overlay := write("function.prod.jsonnet", `{
+  Timeout: '10',
+  Enviroment: {},
+}
+`)
+
+	tests := []struct {
+		files    []string
+		src      string
+		expected []string
+	}{
+		{
+			files: []string{templated},
+			src:   `{"Description":"a multi-line description\nis rendered","MemorySize":"128"}`,
+			expected: []string{
+				templated + `:3:17: type mismatch: expected integer, got string at $.MemorySize`,
+			},
+		},
+		{
+			files: []string{unparsable},
+			src:   `{"MemorySize":128,"Foo":"bar"}`,
+			expected: []string{
+				unparsable + `: unknown field "Foo" at $.Foo`,
+			},
+		},
+		{
+			files: []string{base, overlay},
+			src:   `{"Enviroment":{},"FunctionName":"hello","MemorySize":"128","Timeout":"10"}`,
+			expected: []string{
+				base + `:3:17: type mismatch: expected integer, got string at $.MemorySize`,
+				overlay + `:2:3: type mismatch: expected integer, got string at $.Timeout`,
+				overlay + `:3:3: unknown field "Enviroment" at $.Enviroment`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		var fn Function
+		errs := strictCheckDefinition([]byte(tt.src), &fn, tt.files...)
+		got := make([]string, 0, len(errs))
+		for _, e := range errs {
+			got = append(got, e.Error())
+		}
+		if diff := cmp.Diff(tt.expected, got); diff != "" {
+			t.Errorf("%v: %s", tt.files, diff)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package lambroll
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/fujiwara/tfstate-lookup/tfstate"
+)
+
+// templatizeMinLength is the minimum length of values in tfstate and SSM parameters to templatize.
+// Short values (e.g. "true", "1") may match unrelated parts of the definition.
+const templatizeMinLength = 8
+
+// templateReplacement represents a value to be replaced with a template function or a Jsonnet ext var.
+type templateReplacement struct {
+	value    string
+	template string // e.g. {{ must_env `AWS_ACCOUNT_ID` }}
+	extVar   string // Jsonnet ext var name. if empty, the template is used in Jsonnet too
+}
+
+// templatizer replaces account and region specific values in the definitions.
+type templatizer struct {
+	replacements []*templateReplacement
+	re           *regexp.Regexp
+	used         map[string]bool
+}
+
+func newTemplatizer(rs []*templateReplacement) *templatizer {
+	t := &templatizer{used: make(map[string]bool)}
+	seen := make(map[string]bool)
+	for _, r := range rs {
+		if r.value == "" || seen[r.value] {
+			continue // first one wins
+		}
+		seen[r.value] = true
+		t.replacements = append(t.replacements, r)
+	}
+	// longer values first to prefer tfstate values (e.g. ARN) over the account ID in them
+	sort.SliceStable(t.replacements, func(i, j int) bool {
+		return len(t.replacements[i].value) > len(t.replacements[j].value)
+	})
+	if len(t.replacements) == 0 {
+		return t
+	}
+	patterns := make([]string, 0, len(t.replacements))
+	for _, r := range t.replacements {
+		patterns = append(patterns, regexp.QuoteMeta(r.value))
+	}
+	t.re = regexp.MustCompile(strings.Join(patterns, "|"))
+	return t
+}
+
+var jsonStringLiteralRegexp = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+
+// templatize replaces the values in string literals of the JSON. Object keys are kept as is.
+// If jsonnet is true, the values which have extVar are replaced with std.extVar() and
+// the result is a Jsonnet expression to be formatted by jsonToJsonnet.
+func (t *templatizer) templatize(b []byte, jsonnet bool) []byte {
+	if t == nil || t.re == nil {
+		return b
+	}
+	var out bytes.Buffer
+	last := 0
+	for _, loc := range jsonStringLiteralRegexp.FindAllIndex(b, -1) {
+		out.Write(b[last:loc[0]])
+		last = loc[1]
+		lit := b[loc[0]:loc[1]]
+		if isJSONObjectKey(b[loc[1]:]) {
+			out.Write(lit)
+			continue
+		}
+		out.Write(t.templatizeLiteral(lit, jsonnet))
+	}
+	out.Write(b[last:])
+	return out.Bytes()
+}
+
+// isJSONObjectKey reports whether the string literal followed by rest is an object key.
+func isJSONObjectKey(rest []byte) bool {
+	rest = bytes.TrimLeft(rest, " \t\r\n")
+	return len(rest) > 0 && rest[0] == ':'
+}
+
+func (t *templatizer) templatizeLiteral(lit []byte, jsonnet bool) []byte {
+	s := string(lit[1 : len(lit)-1])
+	locs := t.re.FindAllStringIndex(s, -1)
+	if len(locs) == 0 {
+		return lit
+	}
+	var parts []string
+	var buf strings.Builder
+	last := 0
+	for _, loc := range locs {
+		r := t.lookup(s[loc[0]:loc[1]])
+		buf.WriteString(s[last:loc[0]])
+		last = loc[1]
+		t.used[r.value] = true
+		if jsonnet && r.extVar != "" {
+			if buf.Len() > 0 {
+				parts = append(parts, `"`+buf.String()+`"`)
+				buf.Reset()
+			}
+			parts = append(parts, fmt.Sprintf("std.extVar('%s')", r.extVar))
+		} else {
+			buf.WriteString(r.template)
+		}
+	}
+	buf.WriteString(s[last:])
+	if buf.Len() > 0 || len(parts) == 0 {
+		parts = append(parts, `"`+buf.String()+`"`)
+	}
+	return []byte(strings.Join(parts, " + "))
+}
+
+func (t *templatizer) lookup(value string) *templateReplacement {
+	for _, r := range t.replacements {
+		if r.value == value {
+			return r
+		}
+	}
+	return nil
+}
+
+// extVars returns names of Jsonnet ext vars used by templatize.
+// For JSON, they are the names of environment variables used by must_env.
+func (t *templatizer) extVars() []string {
+	if t == nil {
+		return nil
+	}
+	var names []string
+	for _, r := range t.replacements {
+		if r.extVar != "" && t.used[r.value] {
+			names = append(names, r.extVar)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newTemplatizer creates a templatizer for the account ID, the region,
+// values in the tfstate (--tfstate and --prefixed-tfstate) and values of SSM parameters under the paths.
+func (app *App) newTemplatizer(ctx context.Context, ssmPaths []string) (*templatizer, error) {
+	var rs []*templateReplacement
+	if id := app.AWSAccountID(ctx); id != "" {
+		rs = append(rs, &templateReplacement{
+			value:    id,
+			template: "{{ must_env `AWS_ACCOUNT_ID` }}",
+			extVar:   "AWS_ACCOUNT_ID",
+		})
+	}
+	if region := app.awsConfig.Region; region != "" {
+		rs = append(rs, &templateReplacement{
+			value:    region,
+			template: "{{ must_env `AWS_REGION` }}",
+			extVar:   "AWS_REGION",
+		})
+	}
+
+	prefixes := make([]string, 0, len(app.tfstates))
+	for prefix := range app.tfstates {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		loc := app.tfstates[prefix]
+		state, err := tfstate.ReadURL(ctx, loc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tfstate %s: %w", loc, err)
+		}
+		trs, err := tfstateReplacements(state, prefix+"tfstate")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tfstate %s: %w", loc, err)
+		}
+		rs = append(rs, trs...)
+	}
+
+	if len(ssmPaths) > 0 {
+		srs, err := app.ssmReplacements(ctx, ssmPaths)
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, srs...)
+	}
+	return newTemplatizer(rs), nil
+}
+
+// tfstateReplacements returns replacements for outputs and arn and id attributes of resources in the tfstate.
+func tfstateReplacements(state *tfstate.TFState, funcName string) ([]*templateReplacement, error) {
+	names, err := state.List()
+	if err != nil {
+		return nil, err
+	}
+	var outputs, resources []string
+	for _, name := range names {
+		if strings.HasPrefix(name, "output.") {
+			outputs = append(outputs, name)
+		} else if !strings.HasPrefix(name, "data.terraform_remote_state.") {
+			resources = append(resources, name+".arn", name+".id")
+		}
+	}
+	var rs []*templateReplacement
+	// outputs are preferred to resources
+	for _, key := range append(outputs, resources...) {
+		obj, err := state.Lookup(key)
+		if err != nil {
+			log.Printf("[debug] failed to lookup %s in tfstate: %s", key, err)
+			continue
+		}
+		v, ok := obj.Value.(string)
+		if !ok || len(v) < templatizeMinLength {
+			continue
+		}
+		// tfstate functions accept single quotes instead of double quotes in the address
+		addr := strings.ReplaceAll(key, `"`, "'")
+		rs = append(rs, &templateReplacement{
+			value:    v,
+			template: fmt.Sprintf("{{ %s `%s` }}", funcName, addr),
+		})
+	}
+	return rs, nil
+}
+
+// ssmReplacements returns replacements for SSM parameters under the paths.
+func (app *App) ssmReplacements(ctx context.Context, paths []string) ([]*templateReplacement, error) {
+	svc := ssm.NewFromConfig(app.awsConfig)
+	var rs []*templateReplacement
+	for _, path := range paths {
+		p := ssm.NewGetParametersByPathPaginator(svc, &ssm.GetParametersByPathInput{
+			Path:           aws.String(path),
+			Recursive:      aws.Bool(true),
+			WithDecryption: aws.Bool(true),
+		})
+		for p.HasMorePages() {
+			res, err := p.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get parameters by path %s: %w", path, err)
+			}
+			for _, param := range res.Parameters {
+				v := aws.ToString(param.Value)
+				if param.Type == ssmtypes.ParameterTypeStringList || len(v) < templatizeMinLength {
+					continue
+				}
+				rs = append(rs, &templateReplacement{
+					value:    v,
+					template: fmt.Sprintf("{{ ssm `%s` }}", aws.ToString(param.Name)),
+				})
+			}
+		}
+	}
+	return rs, nil
+}
@@ -0,0 +1,113 @@
+package lambroll
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/fujiwara/tfstate-lookup/tfstate"
+	"github.com/google/go-jsonnet"
+)
+
+func newTestTemplatizer(t *testing.T) *templatizer {
+	t.Helper()
+	state, err := tfstate.ReadFile(context.Background(), "test/terraform.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := []*templateReplacement{
+		{value: "123456789012", template: "{{ must_env `AWS_ACCOUNT_ID` }}", extVar: "AWS_ACCOUNT_ID"},
+		{value: "ap-northeast-1", template: "{{ must_env `AWS_REGION` }}", extVar: "AWS_REGION"},
+		{value: "secret-value-for-test", template: "{{ ssm `/app/secret` }}"},
+	}
+	trs, err := tfstateReplacements(state, "tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newTemplatizer(append(rs, trs...))
+}
+
+func TestTemplatizeJSON(t *testing.T) {
+	tz := newTestTemplatizer(t)
+	src := `{
+  "Role": "arn:aws:iam::123456789012:role/lambda",
+  "Env": "ap-northeast-1",
+  "Secret": "secret-value-for-test",
+  "SecurityGroup": "sg-01a9b01eab0a3c154",
+  "SecurityGroupArn": "arn:aws:ec2:ap-northeast-1:123456789012:security-group/sg-01a9b01eab0a3c154",
+  "Other": "sg-01a9b01eab0a3c154-123456789012",
+  "Number": 123456789012,
+  "ap-northeast-1" : "ap-northeast-1"
+}`
+	expected := map[string]any{
+		"Role":             "arn:aws:iam::{{ must_env `AWS_ACCOUNT_ID` }}:role/lambda",
+		"Env":              "{{ must_env `AWS_REGION` }}",
+		"Secret":           "{{ ssm `/app/secret` }}",
+		"SecurityGroup":    "{{ tfstate `aws_security_group.internal['a'].id` }}",
+		"SecurityGroupArn": "{{ tfstate `aws_security_group.internal['a'].arn` }}",
+		"Other":            "{{ tfstate `aws_security_group.internal['a'].id` }}-{{ must_env `AWS_ACCOUNT_ID` }}",
+		"Number":           float64(123456789012),
+		"ap-northeast-1":   "{{ must_env `AWS_REGION` }}", // keys are not templatized
+	}
+	b := tz.templatize([]byte(src), false)
+	var v map[string]any
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("templatized JSON is invalid: %s\n%s", err, string(b))
+	}
+	for k, e := range expected {
+		if v[k] != e {
+			t.Errorf("%s: expected %v, got %v", k, e, v[k])
+		}
+	}
+	if vars := tz.extVars(); len(vars) != 2 || vars[0] != "AWS_ACCOUNT_ID" || vars[1] != "AWS_REGION" {
+		t.Errorf("unexpected ext vars %v", vars)
+	}
+}
+
+func TestTemplatizeJsonnet(t *testing.T) {
+	tz := newTestTemplatizer(t)
+	src := `{
+  "Role": "arn:aws:iam::123456789012:role/lambda",
+  "Region": "ap-northeast-1",
+  "SecurityGroup": "sg-01a9b01eab0a3c154",
+  "Variables": {"123456789012": "ap-northeast-1"}
+}`
+	b, err := jsonToJsonnet(tz.templatize([]byte(src), true), "function.jsonnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  Role: 'arn:aws:iam::' + std.extVar('AWS_ACCOUNT_ID') + ':role/lambda',
+  Region: std.extVar('AWS_REGION'),
+  SecurityGroup: "{{ tfstate ` + "`aws_security_group.internal['a'].id`" + ` }}",
+  Variables: { '123456789012': std.extVar('AWS_REGION') },
+}
+`
+	if string(b) != expected {
+		t.Errorf("unexpected jsonnet\n%s\nexpected\n%s", string(b), expected)
+	}
+
+	vm := jsonnet.MakeVM()
+	vm.ExtVar("AWS_ACCOUNT_ID", "000000000000")
+	vm.ExtVar("AWS_REGION", "us-east-1")
+	out, err := vm.EvaluateAnonymousSnippet("function.jsonnet", string(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v map[string]any
+	if err := json.Unmarshal([]byte(out), &v); err != nil {
+		t.Fatal(err)
+	}
+	vars, _ := v["Variables"].(map[string]any)
+	if v["Role"] != "arn:aws:iam::000000000000:role/lambda" || v["Region"] != "us-east-1" || vars["123456789012"] != "us-east-1" {
+		t.Errorf("unexpected evaluated jsonnet %s", out)
+	}
+}
+
+func TestTemplatizeNil(t *testing.T) {
+	var tz *templatizer
+	src := []byte(`{"Role": "arn:aws:iam::123456789012:role/lambda"}`)
+	if b := tz.templatize(src, false); string(b) != string(src) {
+		t.Errorf("nil templatizer must not change the source: %s", string(b))
+	}
+}
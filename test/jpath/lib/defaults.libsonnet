@@ -0,0 +1,8 @@
+{
+  TracingConfig: {
+    Mode: 'Active',
+  },
+  Tags: {
+    Owner: 'platform',
+  },
+}
@@ -0,0 +1,5 @@
+{
+  TracingConfig: {
+    Mode: 'PassThrough',
+  },
+}
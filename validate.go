@@ -0,0 +1,319 @@
+package lambroll
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// ValidateOption represents options for Validate()
+type ValidateOption struct {
+	Src         string `help:"function zip archive or src dir" default:"."`
+	SkipArchive bool   `help:"skip to check the handler file in --src. requires Code.S3Bucket and Code.S3Key in function definition" default:"false"`
+	FunctionURL string `help:"path to function-url definiton" default:"" env:"LAMBROLL_FUNCTION_URL"`
+}
+
+// limits of the function configuration
+// https://docs.aws.amazon.com/lambda/latest/dg/gettingstarted-limits.html
+const (
+	minMemorySize           = 128
+	maxMemorySize           = 10240
+	minTimeout              = 1
+	maxTimeout              = 900
+	minEphemeralStorageSize = 512
+	maxEphemeralStorageSize = 10240
+	maxEnvironmentSize      = 4 * 1024
+	maxDescriptionLength    = 256
+)
+
+var (
+	functionNameRegexp   = regexp.MustCompile(`^[a-zA-Z0-9-_]{1,64}$`)
+	functionArnRegexp    = regexp.MustCompile(`^arn:(aws[a-zA-Z-]*)?:lambda:[a-z]{2}(-gov)?-[a-z]+-\d{1}:\d{12}:function:[a-zA-Z0-9-_]{1,64}$`)
+	roleArnRegexp        = regexp.MustCompile(`^arn:(aws[a-zA-Z-]*)?:iam::\d{12}:role/?[a-zA-Z_0-9+=,.@\-_/]+$`)
+	envKeyRegexp         = regexp.MustCompile(`^[a-zA-Z]([a-zA-Z0-9_])+$`)
+	runtimeFamilyRegexp  = regexp.MustCompile(`^(nodejs\d+\.x|python\d\.\d+|java\d+(\.al2)?|dotnet\d+|dotnetcore\d\.\d|ruby\d\.\d+|go1\.x|provided(\.al\d+)?)$`)
+	reservedEnvironments = []string{
+		"_HANDLER", "_X_AMZN_TRACE_ID", "AWS_DEFAULT_REGION", "AWS_REGION", "AWS_EXECUTION_ENV",
+		"AWS_LAMBDA_FUNCTION_NAME", "AWS_LAMBDA_FUNCTION_MEMORY_SIZE", "AWS_LAMBDA_FUNCTION_VERSION",
+		"AWS_LAMBDA_INITIALIZATION_TYPE", "AWS_LAMBDA_LOG_GROUP_NAME", "AWS_LAMBDA_LOG_STREAM_NAME",
+		"AWS_ACCESS_KEY", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+		"AWS_LAMBDA_RUNTIME_API", "LAMBDA_TASK_ROOT", "LAMBDA_RUNTIME_DIR",
+	}
+)
+
+// validationResult represents problems found in the function definition.
+type validationResult struct {
+	Errors   []string
+	Warnings []string
+}
+
+func (r *validationResult) errorf(format string, args ...interface{}) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
+
+func (r *validationResult) warnf(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Validate validates the function definition offline.
+func (app *App) Validate(ctx context.Context, opt *ValidateOption) error {
+	files, src, err := app.renderOverlaidDefinitionFile(app.functionFilePath, DefaultFunctionFilenames)
+	if err != nil {
+		return fmt.Errorf("failed to render function definition: %w", err)
+	}
+	path := files[0]
+	r := &validationResult{}
+	var fn Function
+	for _, e := range strictCheckDefinition(src, &fn, files...) {
+		r.errorf("%s", e)
+	}
+	if err := json.Unmarshal(src, &fn); err != nil && len(r.Errors) == 0 {
+		return fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	validateFunction(&fn, r)
+	if !opt.SkipArchive {
+		validateHandlerFile(&fn, opt.Src, r)
+	} else if !isImageFunction(&fn) && (fn.Code == nil || fn.Code.S3Bucket == nil || fn.Code.S3Key == nil) {
+		r.errorf("--skip-archive requires Code.S3Bucket and Code.S3Key")
+	}
+	if opt.FunctionURL != "" && fn.FunctionName != nil {
+		if _, err := app.loadFunctionUrl(opt.FunctionURL, *fn.FunctionName); err != nil {
+			r.errorf("function url %s: %s", opt.FunctionURL, err)
+		}
+	}
+
+	for _, w := range r.Warnings {
+		log.Printf("[warn] %s", w)
+	}
+	for _, e := range r.Errors {
+		log.Printf("[error] %s", e)
+	}
+	if len(r.Errors) > 0 {
+		return fmt.Errorf("%d errors found in %s", len(r.Errors), path)
+	}
+	log.Printf("[info] %s is valid", path)
+	return nil
+}
+
+func isImageFunction(fn *Function) bool {
+	return fn.PackageType == types.PackageTypeImage || fn.Code != nil && fn.Code.ImageUri != nil
+}
+
+// validateFunction checks the function definition without API calls.
+func validateFunction(fn *Function, r *validationResult) {
+	switch name := aws.ToString(fn.FunctionName); {
+	case name == "":
+		r.errorf("FunctionName is required")
+	case strings.HasPrefix(name, "arn:"):
+		if !functionArnRegexp.MatchString(name) {
+			r.errorf("FunctionName %s is not a valid function ARN", name)
+		}
+	case !functionNameRegexp.MatchString(name):
+		r.errorf("FunctionName %s must be 1-64 characters of letters, numbers, hyphens and underscores", name)
+	}
+
+	switch role := aws.ToString(fn.Role); {
+	case role == "":
+		r.errorf("Role is required")
+	case !roleArnRegexp.MatchString(role):
+		r.errorf("Role %s is not a valid IAM role ARN", role)
+	}
+
+	if d := aws.ToString(fn.Description); len(d) > maxDescriptionLength {
+		r.errorf("Description must be less than or equal to %d characters, got %d", maxDescriptionLength, len(d))
+	}
+	if m := fn.MemorySize; m != nil && (*m < minMemorySize || *m > maxMemorySize) {
+		r.errorf("MemorySize must be between %d and %d, got %d", minMemorySize, maxMemorySize, *m)
+	}
+	if t := fn.Timeout; t != nil && (*t < minTimeout || *t > maxTimeout) {
+		r.errorf("Timeout must be between %d and %d, got %d", minTimeout, maxTimeout, *t)
+	}
+	if e := fn.EphemeralStorage; e != nil && e.Size != nil && (*e.Size < minEphemeralStorageSize || *e.Size > maxEphemeralStorageSize) {
+		r.errorf("EphemeralStorage.Size must be between %d and %d, got %d", minEphemeralStorageSize, maxEphemeralStorageSize, *e.Size)
+	}
+
+	if len(fn.Architectures) > 1 {
+		r.errorf("Architectures must have only one architecture, got %v", fn.Architectures)
+	}
+	for _, a := range fn.Architectures {
+		if !isOneOf(a, types.Architecture("").Values()) {
+			r.errorf("Architectures has unknown architecture %s", a)
+		}
+	}
+
+	validateEnvironment(fn.Environment, r)
+
+	if isImageFunction(fn) {
+		validateImageFunction(fn, r)
+	} else {
+		validateZipFunction(fn, r)
+	}
+}
+
+func validateEnvironment(env *types.Environment, r *validationResult) {
+	if env == nil {
+		return
+	}
+	var size int
+	for k, v := range env.Variables {
+		size += len(k) + len(v)
+		if !envKeyRegexp.MatchString(k) {
+			r.errorf("Environment.Variables key %s must start with a letter and contain only letters, numbers and underscores", k)
+		}
+		if isOneOf(k, reservedEnvironments) {
+			r.errorf("Environment.Variables key %s is reserved by Lambda runtime", k)
+		}
+	}
+	if size > maxEnvironmentSize {
+		r.errorf("Environment.Variables total size must be less than or equal to %d bytes, got %d", maxEnvironmentSize, size)
+	}
+}
+
+func validateImageFunction(fn *Function, r *validationResult) {
+	if fn.PackageType != "" && fn.PackageType != types.PackageTypeImage {
+		r.errorf("Code.ImageUri requires PackageType=Image, got %s", fn.PackageType)
+	}
+	if fn.Code == nil || fn.Code.ImageUri == nil {
+		r.errorf("PackageType=Image requires Code.ImageUri")
+	}
+	if fn.Code != nil && (fn.Code.S3Bucket != nil || fn.Code.S3Key != nil || fn.Code.ZipFile != nil) {
+		r.errorf("PackageType=Image cannot have Code.S3Bucket, Code.S3Key or Code.ZipFile")
+	}
+	if fn.Runtime != "" {
+		r.errorf("PackageType=Image cannot have Runtime")
+	}
+	if fn.Handler != nil {
+		r.errorf("PackageType=Image cannot have Handler. use ImageConfig.Command instead")
+	}
+	if len(fn.Layers) > 0 {
+		r.errorf("PackageType=Image cannot have Layers")
+	}
+}
+
+func validateZipFunction(fn *Function, r *validationResult) {
+	if fn.ImageConfig != nil {
+		r.errorf("ImageConfig is only available for PackageType=Image")
+	}
+	switch {
+	case fn.Runtime == "":
+		r.errorf("Runtime is required for PackageType=Zip")
+	case isOneOf(fn.Runtime, types.Runtime("").Values()):
+		// known runtime
+	case runtimeFamilyRegexp.MatchString(string(fn.Runtime)):
+		r.warnf("Runtime %s is not known by this version of lambroll", fn.Runtime)
+	default:
+		r.errorf("Runtime %s is not a valid runtime", fn.Runtime)
+	}
+	if aws.ToString(fn.Handler) == "" {
+		r.errorf("Handler is required for PackageType=Zip")
+	}
+}
+
+// handlerFiles returns candidates of the file which implements the handler.
+// It returns nil when the handler file cannot be determined (e.g. java, dotnet).
+func handlerFiles(runtime types.Runtime, handler string) []string {
+	rt := string(runtime)
+	dir, base := filepath.Split(handler)
+	switch {
+	case strings.HasPrefix(rt, "provided"):
+		return []string{"bootstrap"}
+	case rt == string(types.RuntimeGo1x):
+		return []string{handler}
+	case strings.HasPrefix(rt, "nodejs"):
+		i := strings.LastIndex(base, ".")
+		if i < 0 {
+			return nil
+		}
+		name := dir + base[:i]
+		return []string{name + ".js", name + ".mjs", name + ".cjs"}
+	case strings.HasPrefix(rt, "python"):
+		i := strings.LastIndex(base, ".")
+		if i < 0 {
+			return nil
+		}
+		module := base[:i]
+		files := []string{dir + module + ".py"}
+		if strings.Contains(module, ".") {
+			// dotted module path
+			files = append(files, dir+strings.ReplaceAll(module, ".", "/")+".py")
+		}
+		return files
+	case strings.HasPrefix(rt, "ruby"):
+		i := strings.Index(base, ".")
+		if i < 0 {
+			return nil
+		}
+		return []string{dir + base[:i] + ".rb"}
+	}
+	return nil
+}
+
+// validateHandlerFile checks the handler file exists in src dir or zip archive.
+func validateHandlerFile(fn *Function, src string, r *validationResult) {
+	if isImageFunction(fn) || fn.Handler == nil {
+		return
+	}
+	candidates := handlerFiles(fn.Runtime, *fn.Handler)
+	if len(candidates) == 0 {
+		log.Printf("[debug] skip checking the handler file for %s", fn.Runtime)
+		return
+	}
+	exists, err := handlerFileExists(src, candidates)
+	if err != nil {
+		r.errorf("failed to check the handler file in %s: %s", src, err)
+		return
+	}
+	if exists {
+		return
+	}
+	msg := fmt.Sprintf("handler file for %s (%s) is not found in %s", *fn.Handler, strings.Join(candidates, " or "), src)
+	if len(fn.Layers) > 0 {
+		r.warnf("%s. it may be provided by the layers", msg)
+	} else {
+		r.errorf("%s", msg)
+	}
+}
+
+func handlerFileExists(src string, candidates []string) (bool, error) {
+	fi, err := os.Stat(src)
+	if err != nil {
+		return false, err
+	}
+	if fi.IsDir() {
+		for _, c := range candidates {
+			if _, err := os.Stat(filepath.Join(src, c)); err == nil {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	z, err := zip.OpenReader(src)
+	if err != nil {
+		return false, err
+	}
+	defer z.Close()
+	for _, f := range z.File {
+		if isOneOf(f.Name, candidates) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isOneOf[T comparable](v T, values []T) bool {
+	for _, x := range values {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
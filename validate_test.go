@@ -0,0 +1,185 @@
+package lambroll
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func validFunction() *Function {
+	return &Function{
+		FunctionName:  aws.String("hello"),
+		Role:          aws.String("arn:aws:iam::123456789012:role/lambda"),
+		Runtime:       types.RuntimeNodejs18x,
+		Handler:       aws.String("index.handler"),
+		MemorySize:    aws.Int32(128),
+		Timeout:       aws.Int32(3),
+		Architectures: []types.Architecture{types.ArchitectureArm64},
+		Environment: &types.Environment{
+			Variables: map[string]string{"FOO": "bar"},
+		},
+	}
+}
+
+var validateFunctionTests = []struct {
+	name   string
+	modify func(fn *Function)
+	errors []string
+}{
+	{
+		name:   "valid",
+		modify: func(fn *Function) {},
+	},
+	{
+		name: "required",
+		modify: func(fn *Function) {
+			fn.FunctionName = nil
+			fn.Role = nil
+			fn.Runtime = ""
+			fn.Handler = nil
+		},
+		errors: []string{"FunctionName is required", "Role is required", "Runtime is required", "Handler is required"},
+	},
+	{
+		name: "enums",
+		modify: func(fn *Function) {
+			fn.Runtime = "node18"
+			fn.Architectures = []types.Architecture{"x86"}
+		},
+		errors: []string{"unknown architecture x86", "Runtime node18 is not a valid runtime"},
+	},
+	{
+		name: "ranges",
+		modify: func(fn *Function) {
+			fn.MemorySize = aws.Int32(64)
+			fn.Timeout = aws.Int32(901)
+			fn.EphemeralStorage = &types.EphemeralStorage{Size: aws.Int32(20480)}
+		},
+		errors: []string{"MemorySize must be between", "Timeout must be between", "EphemeralStorage.Size must be between"},
+	},
+	{
+		name: "environment",
+		modify: func(fn *Function) {
+			fn.Environment.Variables["AWS_REGION"] = "us-east-1"
+			fn.Environment.Variables["1FOO"] = "x"
+			fn.Environment.Variables["LARGE"] = strings.Repeat("x", 4096)
+		},
+		errors: []string{"key 1FOO must start with a letter", "key AWS_REGION is reserved", "total size must be less than or equal to 4096"},
+	},
+	{
+		name: "role arn",
+		modify: func(fn *Function) {
+			fn.Role = aws.String("lambda-role")
+		},
+		errors: []string{"Role lambda-role is not a valid IAM role ARN"},
+	},
+	{
+		name: "image with zip fields",
+		modify: func(fn *Function) {
+			fn.PackageType = types.PackageTypeImage
+		},
+		errors: []string{"requires Code.ImageUri", "cannot have Runtime", "cannot have Handler"},
+	},
+	{
+		name: "image uri with zip package type",
+		modify: func(fn *Function) {
+			fn.PackageType = types.PackageTypeZip
+			fn.Runtime = ""
+			fn.Handler = nil
+			fn.Code = &types.FunctionCode{ImageUri: aws.String("123456789012.dkr.ecr.us-east-1.amazonaws.com/hello:latest")}
+		},
+		errors: []string{"Code.ImageUri requires PackageType=Image"},
+	},
+}
+
+func TestValidateFunction(t *testing.T) {
+	for _, tt := range validateFunctionTests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := validFunction()
+			tt.modify(fn)
+			r := &validationResult{}
+			validateFunction(fn, r)
+			if len(r.Errors) != len(tt.errors) {
+				t.Fatalf("unexpected errors %d: %v", len(r.Errors), r.Errors)
+			}
+			for _, expected := range tt.errors {
+				found := false
+				for _, e := range r.Errors {
+					if strings.Contains(e, expected) {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("error %q is not found in %v", expected, r.Errors)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateFunctionUnknownRuntime(t *testing.T) {
+	fn := validFunction()
+	fn.Runtime = "nodejs99.x"
+	r := &validationResult{}
+	validateFunction(fn, r)
+	if len(r.Errors) != 0 || len(r.Warnings) != 1 {
+		t.Errorf("unexpected result %#v", r)
+	}
+}
+
+func TestHandlerFiles(t *testing.T) {
+	tests := []struct {
+		runtime types.Runtime
+		handler string
+		files   []string
+	}{
+		{types.RuntimeNodejs18x, "src/index.handler", []string{"src/index.js", "src/index.mjs", "src/index.cjs"}},
+		{types.RuntimePython312, "app.lambda_handler", []string{"app.py"}},
+		{types.RuntimePython312, "pkg.mod.handler", []string{"pkg.mod.py", "pkg/mod.py"}},
+		{types.RuntimeRuby32, "function.Handler::Process.run", []string{"function.rb"}},
+		{types.RuntimeProvidedal2, "any", []string{"bootstrap"}},
+		{types.RuntimeGo1x, "main", []string{"main"}},
+		{types.RuntimeJava17, "example.Handler::handleRequest", nil},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.files, handlerFiles(tt.runtime, tt.handler)); diff != "" {
+			t.Errorf("%s %s: %s", tt.runtime, tt.handler, diff)
+		}
+	}
+}
+
+func TestValidateHandlerFile(t *testing.T) {
+	tests := []struct {
+		src     string
+		handler string
+		errors  int
+	}{
+		{"test/src", "index.handler", 0},
+		{"test/src", "main.handler", 1},
+		{"test/src.zip", "dir/sub.handler", 1},
+		{"test/src.zip", "hello.handler", 1},
+		{"test/not-found", "index.handler", 1},
+	}
+	for _, tt := range tests {
+		fn := validFunction()
+		fn.Handler = aws.String(tt.handler)
+		r := &validationResult{}
+		validateHandlerFile(fn, tt.src, r)
+		if len(r.Errors) != tt.errors {
+			t.Errorf("%s %s: unexpected errors %v", tt.src, tt.handler, r.Errors)
+		}
+	}
+
+	// zip archive
+	fn := validFunction()
+	fn.Runtime = types.RuntimeGo1x
+	fn.Handler = aws.String("world")
+	r := &validationResult{}
+	validateHandlerFile(fn, "test/src.zip", r)
+	if len(r.Errors) != 0 {
+		t.Errorf("unexpected errors %v", r.Errors)
+	}
+}
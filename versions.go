@@ -27,6 +27,8 @@ type versionsOutput struct {
 	Aliases      []string  `json:"Aliases,omitempty"`
 	LastModified time.Time `json:"LastModified"`
 	Runtime      string    `json:"Runtime"`
+
+	History *DeployHistory `json:"History,omitempty"`
 }
 
 type versionsOutputs []versionsOutput
@@ -49,26 +51,27 @@ func (vo versionsOutputs) TSV() string {
 func (vo versionsOutputs) Table() string {
 	buf := new(strings.Builder)
 	w := tablewriter.NewWriter(buf)
-	w.SetHeader([]string{"Version", "Last Modified", "Aliases", "Runtime"})
+	w.SetHeader([]string{"Version", "Last Modified", "Aliases", "Runtime", "History"})
+	w.SetAutoWrapText(false)
 	for _, v := range vo {
-		w.Append([]string{
-			v.Version,
-			v.LastModified.Local().Format(time.RFC3339),
-			strings.Join(v.Aliases, ","),
-			v.Runtime,
-		})
+		w.Append(v.row())
 	}
 	w.Render()
 	return buf.String()
 }
 
 func (v versionsOutput) TSV() string {
-	return strings.Join([]string{
+	return strings.Join(v.row(), "\t") + "\n"
+}
+
+func (v versionsOutput) row() []string {
+	return []string{
 		v.Version,
 		v.LastModified.Local().Format(time.RFC3339),
 		strings.Join(v.Aliases, ","),
 		v.Runtime,
-	}, "\t") + "\n"
+		v.History.summary(),
+	}
 }
 
 // Versions manages the versions of a Lambda function
@@ -134,6 +137,7 @@ func (app *App) Versions(ctx context.Context, opt *VersionsOption) error {
 			Aliases:      aliases[*v.Version],
 			LastModified: lm,
 			Runtime:      string(v.Runtime),
+			History:      parseDeployHistory(aws.ToString(v.Description)),
 		}
 		if aws.ToString(v.Version) == versionLatest {
 			latestVo = vo
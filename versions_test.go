@@ -14,7 +14,17 @@ var TestFixedTime = time.Date(2023, 8, 30, 12, 34, 56, 0, time.FixedZone("Asia/T
 
 var TestVersionsOutputs = lambroll.VersionsOutputs{
 	{Version: "1", LastModified: TestFixedTime, Runtime: "go1.x"},
-	{Version: "2", LastModified: TestFixedTime, Runtime: "python3.8", Aliases: []string{"current", "latest"}},
+	{Version: "2", LastModified: TestFixedTime, Runtime: "python3.8", Aliases: []string{"current", "latest"},
+		History: &lambroll.DeployHistory{Lambroll: "v1.0.0", DeployedAt: TestFixedTime, GitCommit: "1a2b3c4", GitBranch: "main"}},
+}
+
+// setLocalTimezone sets time.Local to the location of TestFixedTime during the test,
+// because the outputs are formatted in the local time.
+func setLocalTimezone(t *testing.T) {
+	t.Helper()
+	local := time.Local
+	time.Local = TestFixedTime.Location()
+	t.Cleanup(func() { time.Local = local })
 }
 
 func TestVersionsJSON(t *testing.T) {
@@ -33,9 +43,9 @@ func TestVersionsJSON(t *testing.T) {
 }
 
 func TestVersionsTSV(t *testing.T) {
-	t.Setenv("TZ", "UTC+9")
-	expectedTSV := "1\t2023-08-30T12:34:56+09:00\t\tgo1.x\n" +
-		"2\t2023-08-30T12:34:56+09:00\tcurrent,latest\tpython3.8\n"
+	setLocalTimezone(t)
+	expectedTSV := "1\t2023-08-30T12:34:56+09:00\t\tgo1.x\t\n" +
+		"2\t2023-08-30T12:34:56+09:00\tcurrent,latest\tpython3.8\t2023-08-30T12:34:56+09:00 1a2b3c4@main\n"
 
 	if d := cmp.Diff(TestVersionsOutputs.TSV(), expectedTSV); d != "" {
 		t.Errorf("TSV mismatch: diff:%s", d)
@@ -43,15 +53,15 @@ func TestVersionsTSV(t *testing.T) {
 }
 
 func TestVersionsTable(t *testing.T) {
-	t.Setenv("TZ", "UTC+9")
+	setLocalTimezone(t)
 	tableOutput := TestVersionsOutputs.Table()
 	expectedOutput := `
-+---------+---------------------------+----------------+-----------+
-| VERSION |       LAST MODIFIED       |    ALIASES     |  RUNTIME  |
-+---------+---------------------------+----------------+-----------+
-|       1 | 2023-08-30T12:34:56+09:00 |                | go1.x     |
-|       2 | 2023-08-30T12:34:56+09:00 | current,latest | python3.8 |
-+---------+---------------------------+----------------+-----------+
++---------+---------------------------+----------------+-----------+----------------------------------------+
+| VERSION |       LAST MODIFIED       |    ALIASES     |  RUNTIME  |                HISTORY                 |
++---------+---------------------------+----------------+-----------+----------------------------------------+
+|       1 | 2023-08-30T12:34:56+09:00 |                | go1.x     |                                        |
+|       2 | 2023-08-30T12:34:56+09:00 | current,latest | python3.8 | 2023-08-30T12:34:56+09:00 1a2b3c4@main |
++---------+---------------------------+----------------+-----------+----------------------------------------+
 `
 	expectedOutput = expectedOutput[1:] // remove first newline
 